@@ -0,0 +1,175 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"onecv-go-backend/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+const auditTargetsContextKey = "auditTargets"
+const auditTargetIDsContextKey = "auditTargetIDs"
+const auditActionContextKey = "auditAction"
+
+// Recorded instead of notify for dry runs, which send nothing
+const notifyPreviewAuditAction = "notify_preview"
+
+// The body is buffered to be hashed before the handler (and authorization) runs, so its size must be capped.
+// The largest legitimate body, a full registration batch, is far smaller
+const maxAuditedBodyBytes = 1 << 20
+
+// Recorded as the actor when auth is disabled
+const anonymousActor = "anonymous"
+
+// audit records an audit event for every request to a mutating endpoint once it has been handled,
+// whatever its outcome. Handlers name the emails the request was about with setAuditTargets, and the ids of
+// records whose email may change with setAuditTargetIDs. A handler can record a more specific action with setAuditAction
+func audit(action string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxAuditedBodyBytes))
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			respondWithError(c, fmt.Errorf(customErrors["bodyTooLarge"].Message, errors.New("bodyTooLarge"), maxBytesErr.Limit))
+			c.Abort()
+			return
+		} else if err != nil {
+			respondWithError(c, fmt.Errorf(customErrors["invalidDataType"].Message, errors.New("invalidDataType")))
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		payloadHash := sha256.Sum256(body)
+
+		c.Next()
+
+		actor := anonymousActor
+		if authenticated, ok := getPrincipal(c); ok {
+			actor = authenticated.Subject
+		}
+		targets := c.GetStringSlice(auditTargetsContextKey)
+		if targets == nil {
+			targets = []string{}
+		}
+		targetIDs := c.GetStringSlice(auditTargetIDsContextKey)
+		if targetIDs == nil {
+			targetIDs = []string{}
+		}
+
+		recordedAction := action
+		if handlerAction := c.GetString(auditActionContextKey); handlerAction != "" {
+			recordedAction = handlerAction
+		}
+
+		status := c.Writer.Status()
+		event := models.AuditEvent{
+			Actor: actor,
+			Action: recordedAction,
+			TargetEmails: targets,
+			TargetIDs: targetIDs,
+			PayloadHash: hex.EncodeToString(payloadHash[:]),
+			Outcome: auditOutcome(status),
+			Status: status,
+			RequestID: getRequestID(c),
+		}
+
+		// The response has already been sent, so a failure can only be logged. The event is still
+		// recorded if the client has gone away
+		if err := models.RecordAuditEvent(context.WithoutCancel(c.Request.Context()), event); err != nil {
+			requestLogger(c).Error("Unable to record audit event", "action", recordedAction, "error", err)
+		}
+	}
+}
+
+// Same vocabulary as the database operation metrics
+func auditOutcome(status int) string {
+	if status >= 500 {
+		return "error"
+	} else if status >= 400 {
+		return "rejected"
+	}
+	return "ok"
+}
+
+func setAuditTargets(c *gin.Context, emails ...string) {
+	c.Set(auditTargetsContextKey, emails)
+}
+
+func setAuditTargetIDs(c *gin.Context, ids ...string) {
+	c.Set(auditTargetIDsContextKey, ids)
+}
+
+func setAuditAction(c *gin.Context, action string) {
+	c.Set(auditActionContextKey, action)
+}
+
+type auditEventsSuccessBody struct {
+	Events []models.AuditEvent `json:"events"`
+	Pagination paginationBody `json:"pagination"`
+}
+
+func getAuditEvents(c *gin.Context) {
+	filter := models.AuditEventFilter{
+		Actor: c.Query("actor"),
+		Action: c.Query("action"),
+		TargetEmail: normalizeEmail(c.Query("target")),
+		TargetID: c.Query("targetId"),
+		Outcome: c.Query("outcome"),
+	}
+
+	//Parameter validation (timestamps are RFC 3339, limit and offset are bounded integers)
+	var err error
+	if filter.Since, err = parseTimeQuery(c, "since"); err != nil {
+		respondWithError(c, err)
+		return
+	}
+	if filter.Until, err = parseTimeQuery(c, "until"); err != nil {
+		respondWithError(c, err)
+		return
+	}
+	if filter.Page, err = parsePagination(c); err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	events, hasMore, err := models.ListAuditEvents(c.Request.Context(), filter)
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, auditEventsSuccessBody{events, newPaginationBody(filter.Page, hasMore)})
+}
+
+// parseTimeQuery reads an optional RFC 3339 timestamp from the query string
+func parseTimeQuery(c *gin.Context, parameter string) (time.Time, error) {
+	value := c.Query(parameter)
+	if value == "" { return time.Time{}, nil }
+
+	timestamp, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf(customErrors["invalidQueryParameter"].Message, errors.New("invalidQueryParameter"), parameter, "an RFC 3339 timestamp")
+	}
+	return timestamp, nil
+}
+
+// parseIntQuery reads an optional integer between min and max from the query string
+func parseIntQuery(c *gin.Context, parameter string, defaultValue int, min int, max int) (int, error) {
+	value := c.Query(parameter)
+	if value == "" { return defaultValue, nil }
+
+	number, err := strconv.Atoi(value)
+	if err != nil || number < min || number > max {
+		return 0, fmt.Errorf(customErrors["invalidQueryParameter"].Message, errors.New("invalidQueryParameter"), parameter, fmt.Sprintf("an integer between %d and %d", min, max))
+	}
+	return number, nil
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"onecv-go-backend/models"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pashagolub/pgxmock/v3"
+)
+
+func TestGetAuditEvents(t *testing.T) {
+	occurredAt := time.Date(2026, 3, 2, 9, 30, 0, 0, time.UTC)
+	event := models.AuditEvent{
+		ID: 7,
+		OccurredAt: occurredAt,
+		Actor: "tom@gmail.com",
+		Action: "suspend",
+		TargetEmails: []string{"jerry@gmail.com"},
+		TargetIDs: []string{},
+		PayloadHash: "5d41402abc4b2a76b9719d911017c592",
+		Outcome: "ok",
+		Status: 204,
+		RequestID: "req-1",
+	}
+
+	testCases := []struct {
+		testCaseDesc string
+		query string
+		wantSQLConditions string
+		wantArgs []any
+		returnedEvents int
+		wantCode int
+		wantResponseBody any
+	}{
+		{
+			"No filters",
+			"",
+			"",
+			[]any{defaultPageLimit + 1, 0},
+			1,
+			200,
+			auditEventsSuccessBody{[]models.AuditEvent{event}, paginationBody{Limit: defaultPageLimit, Offset: 0}},
+		},
+		{
+			"Filtered and paginated",
+			"?actor=tom@gmail.com&target=Jerry@Gmail.com&since=2026-03-01T00:00:00Z&limit=10&offset=20",
+			"WHERE actor = $1 AND $2 = ANY(target_emails) AND occurred_at >= $3",
+			[]any{"tom@gmail.com", "jerry@gmail.com", time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), 11, 20},
+			1,
+			200,
+			auditEventsSuccessBody{[]models.AuditEvent{event}, paginationBody{Limit: 10, Offset: 20}},
+		},
+		{
+			"Filtered by the id of a student whose email changed",
+			"?action=update_student&targetId=0b0f8c3e-4a55-4b9e-9d0f-7f1a3c2b6e11",
+			"WHERE action = $1 AND $2 = ANY(target_ids)",
+			[]any{"update_student", "0b0f8c3e-4a55-4b9e-9d0f-7f1a3c2b6e11", defaultPageLimit + 1, 0},
+			1,
+			200,
+			auditEventsSuccessBody{[]models.AuditEvent{event}, paginationBody{Limit: defaultPageLimit, Offset: 0}},
+		},
+		{
+			"More events than the limit",
+			"?limit=1&offset=3",
+			"",
+			[]any{2, 3},
+			2,
+			200,
+			auditEventsSuccessBody{[]models.AuditEvent{event}, paginationBody{Limit: 1, Offset: 3, NextOffset: &[]int{4}[0]}},
+		},
+		{
+			"Invalid timestamp",
+			"?until=yesterday",
+			"",
+			nil,
+			0,
+			400,
+			errorResponseBody{Message: fmt.Errorf(customErrors["invalidQueryParameter"].Message, errors.New("invalidQueryParameter"), "until", "an RFC 3339 timestamp").Error()},
+		},
+		{
+			"Limit too large",
+			"?limit=1000",
+			"",
+			nil,
+			0,
+			400,
+			errorResponseBody{Message: fmt.Errorf(customErrors["invalidQueryParameter"].Message, errors.New("invalidQueryParameter"), "limit", "an integer between 1 and 500").Error()},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testCaseDesc, func(t *testing.T) {
+			mock, err := pgxmock.NewConn()
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer mock.Close(context.Background())
+
+			if tc.wantArgs != nil {
+				rows := pgxmock.NewRows([]string{"id", "occurred_at", "actor", "action", "target_emails", "target_ids", "payload_hash", "outcome", "status", "request_id"})
+				for index := 0; index < tc.returnedEvents; index++ {
+					rows.AddRow(event.ID, event.OccurredAt, event.Actor, event.Action, event.TargetEmails, event.TargetIDs, event.PayloadHash, event.Outcome, event.Status, event.RequestID)
+				}
+
+				mock.ExpectQuery(regexp.QuoteMeta(fmt.Sprintf(`
+		SELECT id, occurred_at, actor, action, target_emails, target_ids, payload_hash, outcome, status, request_id
+		FROM audit_event
+		%s
+		ORDER BY occurred_at DESC, id DESC
+		LIMIT $%d OFFSET $%d
+	`, tc.wantSQLConditions, len(tc.wantArgs)-1, len(tc.wantArgs)))).WithArgs(tc.wantArgs...).WillReturnRows(rows)
+			}
+			models.DB = mock
+
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest("GET", "/api/audit"+tc.query, nil)
+			if err != nil {
+				t.Fatalf("building request: %v", err)
+			}
+
+			testRouter.ServeHTTP(recorder, request)
+
+			checkQueryExpectations(mock, t)
+			checkStatusAndResponse[auditEventsSuccessBody](recorder, t, testCaseStruct{tc.wantCode, tc.wantResponseBody})
+		})
+	}
+}
+
+func TestAuditRejectsLargeBodies(t *testing.T) {
+	mock, err := pgxmock.NewConn()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mock.Close(context.Background())
+	models.DB = mock
+
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest("POST", "/api/suspend", strings.NewReader(`{"student": "`+strings.Repeat("a", maxAuditedBodyBytes)+`@gmail.com"}`))
+	if err != nil {
+		t.Fatalf("building request: %v", err)
+	}
+
+	testRouter.ServeHTTP(recorder, request)
+
+	checkQueryExpectations(mock, t)
+	checkStatusAndResponse[errorResponseBody](recorder, t, testCaseStruct{
+		customErrors["bodyTooLarge"].Status,
+		errorResponseBody{Message: fmt.Errorf(customErrors["bodyTooLarge"].Message, errors.New("bodyTooLarge"), maxAuditedBodyBytes).Error()},
+	})
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"strings"
+
+	"onecv-go-backend/config"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const apiKeyHeader = "X-API-Key"
+const principalContextKey = "principal"
+
+// principal is the caller a request was authenticated as
+type principal struct {
+	// The token's subject (a teacher's or administrator's email) or the API key's name
+	Subject string
+	// "teacher", "admin" or empty for read-only callers
+	Role string
+	// "jwt" or "apiKey"
+	Method string
+}
+
+type tokenClaims struct {
+	Role string `json:"role"`
+	jwt.RegisteredClaims
+}
+
+// authenticate rejects requests without a valid bearer token or API key and stores the caller's
+// principal for handlers to use. It lets every request through when auth is disabled
+func authenticate(cfg config.AuthConfig) gin.HandlerFunc {
+	if !cfg.Enabled {
+		return func(c *gin.Context) { c.Next() }
+	}
+
+	authenticateCredentials := newAuthenticator(cfg)
+	return func(c *gin.Context) {
+		authenticated, err := authenticateCredentials(c.GetHeader(apiKeyHeader), c.GetHeader("Authorization"))
+		if err != nil {
+			c.Header("WWW-Authenticate", `Bearer realm="onecv"`)
+			respondWithError(c, err)
+			c.Abort()
+			return
+		}
+
+		c.Set(principalContextKey, authenticated)
+		c.Next()
+	}
+}
+
+// newAuthenticator checks the credentials of a request, given its API key and Authorization header,
+// so that every transport authenticates callers the same way
+func newAuthenticator(cfg config.AuthConfig) func(apiKey string, authorization string) (principal, error) {
+	parserOptions := []jwt.ParserOption{jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithExpirationRequired()}
+	if cfg.JWTIssuer != "" {
+		parserOptions = append(parserOptions, jwt.WithIssuer(cfg.JWTIssuer))
+	}
+	parser := jwt.NewParser(parserOptions...)
+
+	return func(apiKey string, authorization string) (principal, error) {
+		var authenticated principal
+		var err error
+		if apiKey != "" {
+			authenticated, err = authenticateAPIKey(cfg.APIKeys, apiKey)
+		} else if token, isBearer := strings.CutPrefix(authorization, "Bearer "); isBearer && cfg.JWTSecret != "" {
+			authenticated, err = authenticateToken(parser, []byte(cfg.JWTSecret), token)
+		} else {
+			err = errors.New("A bearer token or API key is required")
+		}
+
+		if err != nil {
+			return principal{}, fmt.Errorf(customErrors["unauthenticated"].Message, errors.New("unauthenticated"), err)
+		}
+		return authenticated, nil
+	}
+}
+
+func authenticateToken(parser *jwt.Parser, secret []byte, token string) (principal, error) {
+	claims := tokenClaims{}
+	_, err := parser.ParseWithClaims(token, &claims, func(*jwt.Token) (interface{}, error) { return secret, nil })
+	if err != nil { return principal{}, fmt.Errorf("The bearer token is invalid: %w", err) }
+	if claims.Subject == "" { return principal{}, errors.New("The bearer token has no subject") }
+
+	return principal{Subject: claims.Subject, Role: claims.Role, Method: "jwt"}, nil
+}
+
+func authenticateAPIKey(apiKeys []config.APIKey, key string) (principal, error) {
+	for _, apiKey := range apiKeys {
+		// Constant time so that keys cannot be guessed from response times
+		if subtle.ConstantTimeCompare([]byte(apiKey.Key), []byte(key)) == 1 {
+			return principal{Subject: apiKey.Name, Role: apiKey.Role, Method: "apiKey"}, nil
+		}
+	}
+	return principal{}, errors.New("The API key is invalid")
+}
+
+// getPrincipal returns the caller a request was authenticated as. ok is false when auth is disabled
+func getPrincipal(c *gin.Context) (authenticated principal, ok bool) {
+	value, exists := c.Get(principalContextKey)
+	if !exists { return principal{}, false }
+
+	authenticated, ok = value.(principal)
+	return authenticated, ok
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"onecv-go-backend/config"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+const testJWTSecret = "0123456789abcdef0123456789abcdef"
+
+// testConfig disables auth, which is on by default, for tests of the handlers themselves
+func testConfig() config.Config {
+	cfg := config.Default()
+	cfg.Auth.Enabled = false
+	return cfg
+}
+
+func testAuthConfig() config.Config {
+	cfg := config.Default()
+	cfg.Auth = config.AuthConfig{
+		Enabled:   true,
+		JWTSecret: testJWTSecret,
+		JWTIssuer: "onecv-test",
+		APIKeys:   []config.APIKey{{Name: "reporting", Key: "reporting-key"}},
+	}
+	return cfg
+}
+
+func signTestToken(t *testing.T, claims tokenClaims, secret string) string {
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+	if err != nil {
+		t.Fatalf("signing token: %v", err)
+	}
+	return token
+}
+
+func TestAuthenticate(t *testing.T) {
+	authRouter := router(testAuthConfig(), noop.NewTracerProvider(), newRateLimitStore(testAuthConfig().RateLimit))
+
+	validClaims := func(subject string, issuer string, expiresAt time.Time) tokenClaims {
+		return tokenClaims{Role: "teacher", RegisteredClaims: jwt.RegisteredClaims{Subject: subject, Issuer: issuer, ExpiresAt: jwt.NewNumericDate(expiresAt)}}
+	}
+	later := time.Now().Add(time.Hour)
+
+	testCases := []struct {
+		testCaseDesc string
+		path string
+		headers map[string]string
+		wantStatus int
+	}{
+		{"Health checks are public", "/healthz", nil, 200},
+		{"Missing credentials", "/api/commonstudents?teacher=invalid", nil, 401},
+		{"Valid token", "/api/commonstudents?teacher=invalid", map[string]string{"Authorization": "Bearer " + signTestToken(t, validClaims("tom@gmail.com", "onecv-test", later), testJWTSecret)}, 400},
+		{"Token signed with another key", "/api/commonstudents?teacher=invalid", map[string]string{"Authorization": "Bearer " + signTestToken(t, validClaims("tom@gmail.com", "onecv-test", later), testJWTSecret+"!")}, 401},
+		{"Expired token", "/api/commonstudents?teacher=invalid", map[string]string{"Authorization": "Bearer " + signTestToken(t, validClaims("tom@gmail.com", "onecv-test", time.Now().Add(-time.Minute)), testJWTSecret)}, 401},
+		{"Token from another issuer", "/api/commonstudents?teacher=invalid", map[string]string{"Authorization": "Bearer " + signTestToken(t, validClaims("tom@gmail.com", "elsewhere", later), testJWTSecret)}, 401},
+		{"Token without a subject", "/api/commonstudents?teacher=invalid", map[string]string{"Authorization": "Bearer " + signTestToken(t, validClaims("", "onecv-test", later), testJWTSecret)}, 401},
+		{"Valid API key", "/api/commonstudents?teacher=invalid", map[string]string{apiKeyHeader: "reporting-key"}, 400},
+		{"Invalid API key", "/api/commonstudents?teacher=invalid", map[string]string{apiKeyHeader: "guess"}, 401},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testCaseDesc, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest("GET", tc.path, nil)
+			if err != nil {
+				t.Fatalf("building request: %v", err)
+			}
+			for header, value := range tc.headers {
+				request.Header.Set(header, value)
+			}
+
+			authRouter.ServeHTTP(recorder, request)
+
+			if recorder.Code != tc.wantStatus {
+				t.Errorf("wrong status code:\nwant: %d\n got: %d (%s)", tc.wantStatus, recorder.Code, recorder.Body.String())
+			}
+			if tc.wantStatus == 401 && recorder.Header().Get("WWW-Authenticate") == "" {
+				t.Error("401 responses must have a WWW-Authenticate header")
+			}
+		})
+	}
+}
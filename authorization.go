@@ -0,0 +1,54 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+)
+
+const adminRole = "admin"
+const teacherRole = "teacher"
+
+// requireAdmin is middleware for endpoints that only administrators may use
+func requireAdmin(action string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		authenticated, ok := getPrincipal(c)
+		if err := authorizeAdmin(authenticated, ok, action); err != nil {
+			respondWithError(c, err)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// authorizeAdmin checks that the caller is an administrator. ok is false when auth is disabled
+func authorizeAdmin(authenticated principal, ok bool, action string) error {
+	if ok && authenticated.Role != adminRole {
+		return fmt.Errorf(customErrors["adminRoleRequired"].Message, errors.New("adminRoleRequired"), action)
+	}
+	return nil
+}
+
+// authorizeTeacher checks that the caller may act on behalf of teacher, which must already be normalized.
+// Administrators may act for any teacher, teachers only for themselves. Every caller is allowed when auth is disabled
+func authorizeTeacher(c *gin.Context, teacher string, action string) error {
+	authenticated, ok := getPrincipal(c)
+	return authorizeTeacherAs(authenticated, ok, teacher, action)
+}
+
+// authorizeTeacherAs is authorizeTeacher for a principal that was not authenticated by gin middleware
+func authorizeTeacherAs(authenticated principal, ok bool, teacher string, action string) error {
+	if !ok || authenticated.Role == adminRole {
+		return nil
+	}
+
+	if authenticated.Role != teacherRole {
+		return fmt.Errorf(customErrors["teacherRoleRequired"].Message, errors.New("teacherRoleRequired"), action)
+	}
+	if normalizeEmail(authenticated.Subject) != teacher {
+		return fmt.Errorf(customErrors["notYourself"].Message, errors.New("notYourself"), action, authenticated.Subject)
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"onecv-go-backend/config"
+	"onecv-go-backend/models"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/pashagolub/pgxmock/v3"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+func TestAuthorization(t *testing.T) {
+	cfg := testAuthConfig()
+	cfg.Auth.APIKeys = append(cfg.Auth.APIKeys, config.APIKey{Name: "sis-sync", Key: "sis-sync-key", Role: adminRole})
+	authRouter := router(cfg, noop.NewTracerProvider(), newRateLimitStore(cfg.RateLimit))
+
+	bearer := func(subject string, role string) map[string]string {
+		claims := tokenClaims{Role: role, RegisteredClaims: jwt.RegisteredClaims{Subject: subject, Issuer: "onecv-test", ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour))}}
+		return map[string]string{"Authorization": "Bearer " + signTestToken(t, claims, testJWTSecret)}
+	}
+
+	// Requests that pass authorization reach the stub database, which has no expectations and so fails with a 500
+	testCases := []struct {
+		testCaseDesc string
+		method string
+		path string
+		body any
+		headers map[string]string
+		wantStatus int
+		wantReason string
+	}{
+		{"Teacher registers students to themselves", "POST", "/api/register", models.StudentRegistrationData[string]{Teacher: "tom@gmail.com", Students: []string{"jerry@gmail.com"}}, bearer("Tom@Gmail.com", teacherRole), 500, ""},
+		{"Teacher registers students to another teacher", "POST", "/api/register", models.StudentRegistrationData[string]{Teacher: "quacker@gmail.com", Students: []string{"jerry@gmail.com"}}, bearer("tom@gmail.com", teacherRole), 403, "notYourself"},
+		{"Admin registers students to any teacher", "POST", "/api/register", models.StudentRegistrationData[string]{Teacher: "quacker@gmail.com", Students: []string{"jerry@gmail.com"}}, bearer("principal@gmail.com", adminRole), 500, ""},
+		{"Read-only API key registers students", "POST", "/api/register", models.StudentRegistrationData[string]{Teacher: "tom@gmail.com", Students: []string{"jerry@gmail.com"}}, map[string]string{apiKeyHeader: "reporting-key"}, 403, "teacherRoleRequired"},
+		{"Teacher notifies as themselves", "POST", "/api/retrievefornotifications", models.RetrieveForNotificationsData{Teacher: "tom@gmail.com", Notification: "Hello"}, bearer("tom@gmail.com", teacherRole), 500, ""},
+		{"Teacher notifies as another teacher", "POST", "/api/retrievefornotifications", models.RetrieveForNotificationsData{Teacher: "quacker@gmail.com", Notification: "Hello"}, bearer("tom@gmail.com", teacherRole), 403, "notYourself"},
+		{"Teacher suspends a student", "POST", "/api/suspend", models.StudentSuspensionData[string]{Student: "jerry@gmail.com"}, bearer("tom@gmail.com", teacherRole), 403, "adminRoleRequired"},
+		{"Admin suspends a student", "POST", "/api/suspend", models.StudentSuspensionData[string]{Student: "jerry@gmail.com"}, bearer("principal@gmail.com", adminRole), 500, ""},
+		{"Admin API key suspends a student", "POST", "/api/suspend", models.StudentSuspensionData[string]{Student: "jerry@gmail.com"}, map[string]string{apiKeyHeader: "sis-sync-key"}, 500, ""},
+		{"Teacher updates a student", "PATCH", "/api/students/5f0c7a8e-3b1d-4c8a-9d2e-1a2b3c4d5e6f", models.StudentUpdateData{Email: "jerry@gmail.com"}, bearer("tom@gmail.com", teacherRole), 403, "adminRoleRequired"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testCaseDesc, func(t *testing.T) {
+			mock, err := pgxmock.NewConn()
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer mock.Close(context.Background())
+			models.DB = mock
+
+			body, err := json.Marshal(tc.body)
+			if err != nil {
+				t.Fatalf("encoding body: %v", err)
+			}
+
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest(tc.method, tc.path, bytes.NewReader(body))
+			if err != nil {
+				t.Fatalf("building request: %v", err)
+			}
+			for header, value := range tc.headers {
+				request.Header.Set(header, value)
+			}
+
+			authRouter.ServeHTTP(recorder, request)
+
+			if recorder.Code != tc.wantStatus {
+				t.Fatalf("wrong status code:\nwant: %d\n got: %d (%s)", tc.wantStatus, recorder.Code, recorder.Body.String())
+			}
+
+			var responseBody errorResponseBody
+			if err := json.Unmarshal(recorder.Body.Bytes(), &responseBody); err != nil {
+				t.Fatalf("decoding response: %v", err)
+			}
+			if responseBody.Reason != tc.wantReason {
+				t.Errorf("wrong reason:\nwant: %q\n got: %q", tc.wantReason, responseBody.Reason)
+			}
+		})
+	}
+}
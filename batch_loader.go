@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"sync"
+)
+
+// batchLoader collects the keys requested while a GraphQL query resolves one level of the tree, and loads
+// them all with a single call once the executor asks for the first of them. Results are cached, so a loader
+// must only live as long as one request
+type batchLoader[V any] struct {
+	mutex sync.Mutex
+	loadBatch func(ctx context.Context, keys []string) (map[string]V, error)
+	pending []string
+	requested map[string]bool
+	results map[string]V
+	errs map[string]error
+}
+
+func newBatchLoader[V any](loadBatch func(ctx context.Context, keys []string) (map[string]V, error)) *batchLoader[V] {
+	return &batchLoader[V]{loadBatch: loadBatch, requested: map[string]bool{}, results: map[string]V{}, errs: map[string]error{}}
+}
+
+// load queues key and returns a thunk that reports its value, and whether it was found
+func (loader *batchLoader[V]) load(ctx context.Context, key string) func() (V, bool, error) {
+	loader.mutex.Lock()
+	if !loader.requested[key] {
+		loader.requested[key] = true
+		loader.pending = append(loader.pending, key)
+	}
+	loader.mutex.Unlock()
+
+	return func() (V, bool, error) {
+		loader.mutex.Lock()
+		defer loader.mutex.Unlock()
+
+		if len(loader.pending) > 0 {
+			keys := loader.pending
+			loader.pending = nil
+
+			results, err := loader.loadBatch(ctx, keys)
+			for _, batchKey := range keys {
+				if err != nil {
+					loader.errs[batchKey] = err
+				} else if value, found := results[batchKey]; found {
+					loader.results[batchKey] = value
+				}
+			}
+		}
+
+		value, found := loader.results[key]
+		return value, found, loader.errs[key]
+	}
+}
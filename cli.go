@@ -0,0 +1,198 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"onecv-go-backend/config"
+	"onecv-go-backend/migrations"
+	"onecv-go-backend/models"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const usage = `usage: onecv [flags] <command> [arguments]
+
+flags:
+  -config <file.yaml>      YAML configuration file (CONFIG_FILE)
+  -addr <address>          address to listen on (LISTEN_ADDR)
+  -database-url <url>      PostgreSQL connection string (DATABASE_URL)
+  -log-level <level>       debug, info, warn or error (LOG_LEVEL)
+  -log-format <format>     json or text (LOG_FORMAT)
+
+commands:
+  serve                             run the API server (default)
+  migrate <up|down [steps]|status>  manage database migrations
+  teacher add <email>...            add teachers
+  student add <email>...            add students
+  student suspend <email>...        suspend students
+  register <teacher> <student>...   register students with a teacher
+  import <file.csv>                 import a roster of teacher,student rows
+  seed [fixture.json|fixture.yaml]  load a fixture of demo data (fixtures/demo.json by default)
+  help                              show this message`
+
+func isHelpCommand(args []string) bool {
+	return len(args) > 0 && (args[0] == "help" || args[0] == "-h" || args[0] == "--help")
+}
+
+func runCommand(cfg config.Config, pool *pgxpool.Pool, tracerProvider trace.TracerProvider, args []string) error {
+	if len(args) == 0 {
+		return serve(cfg, pool, tracerProvider)
+	}
+
+	switch args[0] {
+	case "serve":
+		return serve(cfg, pool, tracerProvider)
+	case "migrate":
+		return runMigrateCommand(pool, args[1:])
+	}
+
+	// The other commands write through the models layer, which expects the latest schema
+	if err := requireMigrated(context.Background(), pool); err != nil { return err }
+
+	switch args[0] {
+	case "teacher":
+		return runTeacherCommand(args[1:])
+	case "student":
+		return runStudentCommand(args[1:])
+	case "register":
+		return runRegisterCommand(args[1:])
+	case "import":
+		return runImportCommand(args[1:])
+	case "seed":
+		return runSeedCommand(args[1:])
+	default:
+		return fmt.Errorf("unknown command '%s'\n%s", args[0], usage)
+	}
+}
+
+// requireMigrated fails unless every migration has been applied. Unlike serve, commands do not migrate the
+// database themselves, as they may be run against a database that another version of the server is using
+func requireMigrated(ctx context.Context, querier migrations.Querier) error {
+	unapplied, err := migrations.Unapplied(ctx, querier)
+	if err != nil { return fmt.Errorf("Unable to check the database's migrations. Err: %w", err) }
+
+	if len(unapplied) > 0 {
+		pending := []string{}
+		for _, migration := range unapplied {
+			pending = append(pending, fmt.Sprintf("%04d_%s", migration.Version, migration.Name))
+		}
+		return fmt.Errorf("The database has unapplied migrations (%s). Run 'migrate up' first", strings.Join(pending, ", "))
+	}
+	return nil
+}
+
+func runTeacherCommand(args []string) error {
+	if len(args) < 2 || args[0] != "add" {
+		return errors.New("usage: teacher add <email>...")
+	}
+
+	teachers, err := normalizeAndValidateEmails(args[1:])
+	if err != nil { return err }
+
+	for _, teacher := range teachers {
+		if err := models.AddTeacher(context.Background(), teacher); err != nil { return err }
+		fmt.Fprintf(os.Stdout, "Added teacher '%s'\n", teacher)
+	}
+	return nil
+}
+
+func runStudentCommand(args []string) error {
+	if len(args) < 2 || (args[0] != "add" && args[0] != "suspend") {
+		return errors.New("usage: student add <email>... | student suspend <email>...")
+	}
+
+	students, err := normalizeAndValidateEmails(args[1:])
+	if err != nil { return err }
+
+	for _, student := range students {
+		if args[0] == "add" {
+			if err := models.AddStudent(context.Background(), student); err != nil { return err }
+			fmt.Fprintf(os.Stdout, "Added student '%s'\n", student)
+		} else {
+			if err := models.SuspendStudent(context.Background(), models.StudentSuspensionData[string]{Student: student}); err != nil { return err }
+			fmt.Fprintf(os.Stdout, "Suspended student '%s'\n", student)
+		}
+	}
+	return nil
+}
+
+func runRegisterCommand(args []string) error {
+	if len(args) < 2 {
+		return errors.New("usage: register <teacher> <student>...")
+	}
+
+	emails, err := normalizeAndValidateEmails(args)
+	if err != nil { return err }
+
+	studentRegistrationData := models.StudentRegistrationData[string]{
+		Teacher: emails[0],
+		Students: removeDuplicateStr(emails[1:]),
+	}
+	if err := models.RegisterStudents(context.Background(), studentRegistrationData); err != nil { return err }
+
+	fmt.Fprintf(os.Stdout, "Registered %d student(s) with '%s'\n", len(studentRegistrationData.Students), studentRegistrationData.Teacher)
+	return nil
+}
+
+func runImportCommand(args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: import <file.csv>")
+	}
+
+	file, err := os.Open(args[0])
+	if err != nil { return err }
+	defer file.Close()
+
+	roster, err := readRoster(file)
+	if err != nil { return fmt.Errorf("%s: %w", args[0], err) }
+
+	summary, err := models.ImportRoster(context.Background(), roster)
+	if err != nil { return err }
+
+	fmt.Fprintf(os.Stdout, "Added %d teacher(s), %d student(s) and %d registration(s)\n", summary.TeachersAdded, summary.StudentsAdded, summary.RegistrationsAdded)
+	return nil
+}
+
+// readRoster parses teacher,student rows. A leading "teacher,student" header row is optional
+func readRoster(reader io.Reader) ([]models.RosterEntry, error) {
+	csvReader := csv.NewReader(reader)
+	csvReader.FieldsPerRecord = 2
+	csvReader.TrimLeadingSpace = true
+
+	records, err := csvReader.ReadAll()
+	if err != nil { return nil, err }
+
+	firstLine := 1
+	if len(records) > 0 && strings.EqualFold(records[0][0], "teacher") && strings.EqualFold(records[0][1], "student") {
+		records = records[1:]
+		firstLine = 2
+	}
+
+	roster := []models.RosterEntry{}
+	for index, record := range records {
+		emails, err := normalizeAndValidateEmails(record)
+		if err != nil { return nil, fmt.Errorf("line %d: %w", firstLine+index, err) }
+
+		roster = append(roster, models.RosterEntry{Teacher: emails[0], Student: emails[1]})
+	}
+
+	return roster, nil
+}
+
+func normalizeAndValidateEmails(emails []string) ([]string, error) {
+	emails = normalizeEmails(emails)
+
+	invalidEmails := getInvalidEmails(emails)
+	if haveInvalidEmails := len(invalidEmails) > 0; haveInvalidEmails {
+		return nil, fmt.Errorf(customErrors["invalidEmail"].Message, errors.New("invalidEmail"), strings.Join(invalidEmails, ", "))
+	}
+
+	return emails, nil
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"onecv-go-backend/migrations"
+	"onecv-go-backend/models"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/pashagolub/pgxmock/v3"
+)
+
+func TestReadRoster(t *testing.T) {
+	testCases := []struct {
+		testCaseDesc string
+		csv string
+		wantRoster []models.RosterEntry
+		wantErr string
+	}{
+		{
+			"Rows without a header",
+			"tom@gmail.com,jerry@gmail.com\nTom@Gmail.com, Spike@gmail.com\n",
+			[]models.RosterEntry{{Teacher: "tom@gmail.com", Student: "jerry@gmail.com"}, {Teacher: "tom@gmail.com", Student: "spike@gmail.com"}},
+			"",
+		},
+		{
+			"Rows with a header",
+			"teacher,student\nquacker@gmail.com,tyke@gmail.com\n",
+			[]models.RosterEntry{{Teacher: "quacker@gmail.com", Student: "tyke@gmail.com"}},
+			"",
+		},
+		{
+			"Invalid email",
+			"teacher,student\ntom@gmail.com,jerry@gmail.com\ntom@gmail.com,spikegmail.com\n",
+			nil,
+			fmt.Sprintf("line 3: %s", fmt.Errorf(customErrors["invalidEmail"].Message, errors.New("invalidEmail"), "'spikegmail.com'")),
+		},
+		{
+			"Wrong number of columns",
+			"tom@gmail.com,jerry@gmail.com,true\n",
+			nil,
+			"wrong number of fields",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testCaseDesc, func(t *testing.T) {
+			roster, err := readRoster(strings.NewReader(tc.csv))
+
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Errorf("wrong error:\nwant: %v\n got: %v", tc.wantErr, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("reading roster: %v", err)
+			}
+			if !cmp.Equal(roster, tc.wantRoster) {
+				t.Errorf("wrong roster:\nwant: %v\n got: %v", tc.wantRoster, roster)
+			}
+		})
+	}
+}
+
+func TestRequireMigrated(t *testing.T) {
+	allMigrations, err := migrations.Load()
+	if err != nil {
+		t.Fatalf("loading migrations: %v", err)
+	}
+	latest := allMigrations[len(allMigrations)-1]
+
+	testCases := []struct {
+		testCaseDesc string
+		appliedVersions int
+		wantErr string
+	}{
+		{"Every migration applied", len(allMigrations), ""},
+		{"Latest migration unapplied", len(allMigrations) - 1, fmt.Sprintf("The database has unapplied migrations (%04d_%s). Run 'migrate up' first", latest.Version, latest.Name)},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testCaseDesc, func(t *testing.T) {
+			mock, err := pgxmock.NewConn()
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer mock.Close(context.Background())
+
+			rows := pgxmock.NewRows([]string{"version", "applied_at"})
+			for _, migration := range allMigrations[:tc.appliedVersions] {
+				rows.AddRow(migration.Version, time.Now())
+			}
+			mock.ExpectQuery(regexp.QuoteMeta("SELECT version, applied_at FROM schema_migrations")).WillReturnRows(rows)
+
+			err = requireMigrated(context.Background(), mock)
+			if (err == nil && tc.wantErr != "") || (err != nil && err.Error() != tc.wantErr) {
+				t.Errorf("wrong error:\nwant: %q\n got: %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestImportRosterIsAtomic(t *testing.T) {
+	mock, err := pgxmock.NewConn()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mock.Close(context.Background())
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("INSERT INTO teacher(email) VALUES ($1) ON CONFLICT (email) DO NOTHING RETURNING email")).WithArgs("tom@gmail.com").
+		WillReturnRows(pgxmock.NewRows([]string{"email"}).AddRow("tom@gmail.com"))
+	mock.ExpectQuery(regexp.QuoteMeta("INSERT INTO student(email, suspended) VALUES ($1, false) ON CONFLICT (email) DO NOTHING RETURNING email")).WithArgs("jerry@gmail.com").
+		WillReturnError(errors.New("connection reset"))
+	mock.ExpectRollback()
+	models.DB = mock
+
+	summary, err := models.ImportRoster(context.Background(), []models.RosterEntry{{Teacher: "tom@gmail.com", Student: "jerry@gmail.com"}})
+	if err == nil {
+		t.Error("expected the import to fail")
+	}
+	if summary != (models.RosterImportSummary{}) {
+		t.Errorf("a failed import should add nothing, got %+v", summary)
+	}
+	checkQueryExpectations(mock, t)
+}
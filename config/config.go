@@ -0,0 +1,529 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+type Config struct {
+	Server   ServerConfig   `yaml:"server"`
+	Database DatabaseConfig `yaml:"database"`
+	CORS     CORSConfig     `yaml:"cors"`
+	Auth     AuthConfig     `yaml:"auth"`
+	Log      LogConfig      `yaml:"log"`
+	Tracing  TracingConfig  `yaml:"tracing"`
+	RateLimit RateLimitConfig `yaml:"rateLimit"`
+	Scheduler SchedulerConfig `yaml:"scheduler"`
+}
+
+type ServerConfig struct {
+	Addr              string        `yaml:"addr"`
+	ReadHeaderTimeout time.Duration `yaml:"readHeaderTimeout"`
+	ReadTimeout       time.Duration `yaml:"readTimeout"`
+	WriteTimeout      time.Duration `yaml:"writeTimeout"`
+	IdleTimeout       time.Duration `yaml:"idleTimeout"`
+	ShutdownTimeout   time.Duration `yaml:"shutdownTimeout"`
+	TLSCertFile       string        `yaml:"tlsCertFile"`
+	TLSKeyFile        string        `yaml:"tlsKeyFile"`
+	// The gRPC service is only served when this is set
+	GRPCAddr          string        `yaml:"grpcAddr"`
+}
+
+type DatabaseConfig struct {
+	URL             string        `yaml:"url"`
+	MaxConns        int32         `yaml:"maxConns"`
+	MinConns        int32         `yaml:"minConns"`
+	MaxConnLifetime time.Duration `yaml:"maxConnLifetime"`
+	MaxConnIdleTime time.Duration `yaml:"maxConnIdleTime"`
+	ConnectTimeout  time.Duration `yaml:"connectTimeout"`
+}
+
+type CORSConfig struct {
+	AllowedOrigins []string      `yaml:"allowedOrigins"`
+	AllowedMethods []string      `yaml:"allowedMethods"`
+	AllowedHeaders []string      `yaml:"allowedHeaders"`
+	MaxAge         time.Duration `yaml:"maxAge"`
+}
+
+type AuthConfig struct {
+	Enabled   bool     `yaml:"enabled"`
+	JWTSecret string   `yaml:"jwtSecret"`
+	JWTIssuer string   `yaml:"jwtIssuer"`
+	APIKeys   []APIKey `yaml:"apiKeys"`
+}
+
+type APIKey struct {
+	Name string `yaml:"name"`
+	Key  string `yaml:"key"`
+	// Empty for read-only keys
+	Role string `yaml:"role"`
+}
+
+// The roles an API key may have. Read-only keys have none
+var apiKeyRoles = []string{"", "admin"}
+
+type LogConfig struct {
+	Level  string `yaml:"level"`
+	Format string `yaml:"format"`
+}
+
+type TracingConfig struct {
+	Exporter     string  `yaml:"exporter"`
+	OTLPEndpoint string  `yaml:"otlpEndpoint"`
+	ServiceName  string  `yaml:"serviceName"`
+	SampleRatio  float64 `yaml:"sampleRatio"`
+}
+
+type RateLimitConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// "memory" keeps buckets per instance, "postgres" shares them between instances
+	Store   string    `yaml:"store"`
+	Default RateLimit `yaml:"default"`
+	// Overrides keyed by method and route, e.g. "POST /api/retrievefornotifications"
+	Routes map[string]RateLimit `yaml:"routes"`
+	// Shared by every route and applied before authentication, so that guessing credentials is limited too
+	PerIP RateLimit `yaml:"perIP"`
+}
+
+// RateLimit is a token bucket: clients may make Burst requests at once, refilled at Rate requests per second
+type RateLimit struct {
+	Rate  float64 `yaml:"rate"`
+	Burst int     `yaml:"burst"`
+}
+
+// SchedulerConfig controls the background sending of scheduled notifications
+type SchedulerConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// How often to look for notifications whose send time has come
+	Interval time.Duration `yaml:"interval"`
+	// The most notifications sent per interval
+	BatchSize int `yaml:"batchSize"`
+}
+
+var logLevels = []string{"debug", "info", "warn", "error"}
+var logFormats = []string{"json", "text"}
+var tracingExporters = []string{"none", "stdout", "otlp"}
+var rateLimitStores = []string{"memory", "postgres"}
+
+// HMAC keys shorter than the SHA-256 output size can be brute forced
+const minJWTSecretLength = 32
+
+func Default() Config {
+	return Config{
+		Server: ServerConfig{
+			Addr:              ":8080",
+			ReadHeaderTimeout: 5 * time.Second,
+			ReadTimeout:       15 * time.Second,
+			WriteTimeout:      30 * time.Second,
+			IdleTimeout:       2 * time.Minute,
+			ShutdownTimeout:   20 * time.Second,
+		},
+		Database: DatabaseConfig{
+			MaxConns:        10,
+			MinConns:        0,
+			MaxConnLifetime: time.Hour,
+			MaxConnIdleTime: 30 * time.Minute,
+			ConnectTimeout:  10 * time.Second,
+		},
+		CORS: CORSConfig{
+			AllowedOrigins: []string{},
+			AllowedMethods: []string{"GET", "POST", "PATCH", "PUT", "DELETE", "OPTIONS"},
+			AllowedHeaders: []string{"Authorization", "Content-Type", "X-API-Key"},
+			MaxAge:         12 * time.Hour,
+		},
+		// Secure by default: deployments must configure credentials, or explicitly disable auth for development
+		Auth: AuthConfig{
+			Enabled: true,
+		},
+		Log: LogConfig{
+			Level:  "info",
+			Format: "json",
+		},
+		Tracing: TracingConfig{
+			Exporter:    "none",
+			ServiceName: "onecv-go-backend",
+			SampleRatio: 1,
+		},
+		RateLimit: RateLimitConfig{
+			Enabled: true,
+			Store:   "memory",
+			Default: RateLimit{Rate: 10, Burst: 20},
+			Routes: map[string]RateLimit{
+				// Every call queries the database once per mentioned and registered student
+				"POST /api/retrievefornotifications": {Rate: 1, Burst: 5},
+				"POST /api/v2/notifications":         {Rate: 1, Burst: 5},
+			},
+			PerIP: RateLimit{Rate: 30, Burst: 60},
+		},
+		Scheduler: SchedulerConfig{
+			Enabled:   true,
+			Interval:  30 * time.Second,
+			BatchSize: 100,
+		},
+	}
+}
+
+// Load builds the configuration from, in increasing order of precedence: defaults, the YAML file
+// named by -config or CONFIG_FILE, environment variables (including those in an optional .env file)
+// and command-line flags. Flags must come before the command, whose arguments are returned.
+func Load(args []string) (Config, []string, error) {
+	if err := godotenv.Load(".env"); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return Config{}, nil, fmt.Errorf("reading .env: %w", err)
+	}
+
+	cfg := Default()
+
+	flagSet := flag.NewFlagSet("onecv", flag.ContinueOnError)
+	configFile := flagSet.String("config", os.Getenv("CONFIG_FILE"), "path to a YAML configuration file (CONFIG_FILE)")
+	addr := flagSet.String("addr", "", "address to listen on (LISTEN_ADDR)")
+	databaseURL := flagSet.String("database-url", "", "PostgreSQL connection string (DATABASE_URL)")
+	logLevel := flagSet.String("log-level", "", "one of "+strings.Join(logLevels, ", ")+" (LOG_LEVEL)")
+	logFormat := flagSet.String("log-format", "", "one of "+strings.Join(logFormats, ", ")+" (LOG_FORMAT)")
+	if err := flagSet.Parse(args); err != nil {
+		return Config{}, nil, err
+	}
+
+	if *configFile != "" {
+		contents, err := os.ReadFile(*configFile)
+		if err != nil { return Config{}, nil, fmt.Errorf("reading config file: %w", err) }
+
+		decoder := yaml.NewDecoder(bytes.NewReader(contents))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(&cfg); err != nil && err != io.EOF {
+			return Config{}, nil, fmt.Errorf("parsing config file %s: %w", *configFile, err)
+		}
+	}
+
+	if err := cfg.applyEnv(); err != nil {
+		return Config{}, nil, err
+	}
+
+	for flagValue, field := range map[*string]*string{addr: &cfg.Server.Addr, databaseURL: &cfg.Database.URL, logLevel: &cfg.Log.Level, logFormat: &cfg.Log.Format} {
+		if *flagValue != "" {
+			*field = *flagValue
+		}
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return Config{}, nil, err
+	}
+
+	return cfg, flagSet.Args(), nil
+}
+
+type envVar struct {
+	name string
+	set  func(value string) error
+}
+
+func (cfg *Config) envVars() []envVar {
+	return []envVar{
+		{"LISTEN_ADDR", setString(&cfg.Server.Addr)},
+		{"SERVER_READ_HEADER_TIMEOUT", setDuration(&cfg.Server.ReadHeaderTimeout)},
+		{"SERVER_READ_TIMEOUT", setDuration(&cfg.Server.ReadTimeout)},
+		{"SERVER_WRITE_TIMEOUT", setDuration(&cfg.Server.WriteTimeout)},
+		{"SERVER_IDLE_TIMEOUT", setDuration(&cfg.Server.IdleTimeout)},
+		{"SERVER_SHUTDOWN_TIMEOUT", setDuration(&cfg.Server.ShutdownTimeout)},
+		{"SERVER_TLS_CERT_FILE", setString(&cfg.Server.TLSCertFile)},
+		{"SERVER_TLS_KEY_FILE", setString(&cfg.Server.TLSKeyFile)},
+		{"GRPC_ADDR", setString(&cfg.Server.GRPCAddr)},
+
+		{"DATABASE_URL", setString(&cfg.Database.URL)},
+		{"DB_MAX_CONNS", setInt32(&cfg.Database.MaxConns)},
+		{"DB_MIN_CONNS", setInt32(&cfg.Database.MinConns)},
+		{"DB_MAX_CONN_LIFETIME", setDuration(&cfg.Database.MaxConnLifetime)},
+		{"DB_MAX_CONN_IDLE_TIME", setDuration(&cfg.Database.MaxConnIdleTime)},
+		{"DB_CONNECT_TIMEOUT", setDuration(&cfg.Database.ConnectTimeout)},
+
+		{"CORS_ALLOWED_ORIGINS", setList(&cfg.CORS.AllowedOrigins)},
+		{"CORS_ALLOWED_METHODS", setList(&cfg.CORS.AllowedMethods)},
+		{"CORS_ALLOWED_HEADERS", setList(&cfg.CORS.AllowedHeaders)},
+		{"CORS_MAX_AGE", setDuration(&cfg.CORS.MaxAge)},
+
+		{"AUTH_ENABLED", setBool(&cfg.Auth.Enabled)},
+		{"AUTH_JWT_SECRET", setString(&cfg.Auth.JWTSecret)},
+		{"AUTH_JWT_ISSUER", setString(&cfg.Auth.JWTIssuer)},
+		{"AUTH_API_KEYS", setAPIKeys(&cfg.Auth.APIKeys)},
+
+		{"LOG_LEVEL", setString(&cfg.Log.Level)},
+		{"LOG_FORMAT", setString(&cfg.Log.Format)},
+
+		{"TRACING_EXPORTER", setString(&cfg.Tracing.Exporter)},
+		{"TRACING_OTLP_ENDPOINT", setString(&cfg.Tracing.OTLPEndpoint)},
+		{"TRACING_SERVICE_NAME", setString(&cfg.Tracing.ServiceName)},
+		{"TRACING_SAMPLE_RATIO", setFloat64(&cfg.Tracing.SampleRatio)},
+
+		{"RATE_LIMIT_ENABLED", setBool(&cfg.RateLimit.Enabled)},
+		{"RATE_LIMIT_STORE", setString(&cfg.RateLimit.Store)},
+		{"RATE_LIMIT_RATE", setFloat64(&cfg.RateLimit.Default.Rate)},
+		{"RATE_LIMIT_BURST", setInt(&cfg.RateLimit.Default.Burst)},
+		{"RATE_LIMIT_ROUTES", setRateLimits(&cfg.RateLimit.Routes)},
+		{"RATE_LIMIT_IP_RATE", setFloat64(&cfg.RateLimit.PerIP.Rate)},
+		{"RATE_LIMIT_IP_BURST", setInt(&cfg.RateLimit.PerIP.Burst)},
+
+		{"SCHEDULER_ENABLED", setBool(&cfg.Scheduler.Enabled)},
+		{"SCHEDULER_INTERVAL", setDuration(&cfg.Scheduler.Interval)},
+		{"SCHEDULER_BATCH_SIZE", setInt(&cfg.Scheduler.BatchSize)},
+	}
+}
+
+func (cfg *Config) applyEnv() error {
+	// Hosting platforms such as Render only tell us which port to listen on
+	if port, exists := os.LookupEnv("PORT"); exists {
+		cfg.Server.Addr = ":" + port
+	}
+
+	errs := []error{}
+	for _, envVar := range cfg.envVars() {
+		value, exists := os.LookupEnv(envVar.name)
+		if !exists { continue }
+
+		if err := envVar.set(value); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", envVar.name, err))
+		}
+	}
+
+	return joinErrors("invalid environment variables", errs)
+}
+
+func setString(field *string) func(string) error {
+	return func(value string) error {
+		*field = value
+		return nil
+	}
+}
+
+func setDuration(field *time.Duration) func(string) error {
+	return func(value string) error {
+		duration, err := time.ParseDuration(value)
+		if err != nil { return fmt.Errorf("'%s' is not a duration such as 30s or 5m", value) }
+
+		*field = duration
+		return nil
+	}
+}
+
+func setInt32(field *int32) func(string) error {
+	return func(value string) error {
+		number, err := strconv.ParseInt(value, 10, 32)
+		if err != nil { return fmt.Errorf("'%s' is not an integer", value) }
+
+		*field = int32(number)
+		return nil
+	}
+}
+
+func setInt(field *int) func(string) error {
+	return func(value string) error {
+		number, err := strconv.Atoi(value)
+		if err != nil { return fmt.Errorf("'%s' is not an integer", value) }
+
+		*field = number
+		return nil
+	}
+}
+
+func setFloat64(field *float64) func(string) error {
+	return func(value string) error {
+		number, err := strconv.ParseFloat(value, 64)
+		if err != nil { return fmt.Errorf("'%s' is not a number", value) }
+
+		*field = number
+		return nil
+	}
+}
+
+func setBool(field *bool) func(string) error {
+	return func(value string) error {
+		boolean, err := strconv.ParseBool(value)
+		if err != nil { return fmt.Errorf("'%s' is not true or false", value) }
+
+		*field = boolean
+		return nil
+	}
+}
+
+// Lists are comma separated, e.g. "https://a.example,https://b.example"
+func setList(field *[]string) func(string) error {
+	return func(value string) error {
+		*field = splitList(value)
+		return nil
+	}
+}
+
+// API keys are comma separated name:key pairs, optionally followed by a role,
+// e.g. "reporting:s3cr3t,sis-sync:an0th3r:admin"
+func setAPIKeys(field *[]APIKey) func(string) error {
+	return func(value string) error {
+		apiKeys := []APIKey{}
+		for _, entry := range splitList(value) {
+			name, key, found := strings.Cut(entry, ":")
+			if !found { return fmt.Errorf("'%s' is not a name:key pair", entry) }
+
+			role := ""
+			if separator := strings.LastIndex(key, ":"); separator >= 0 && slices.Contains(apiKeyRoles, key[separator+1:]) {
+				key, role = key[:separator], key[separator+1:]
+			}
+
+			apiKeys = append(apiKeys, APIKey{Name: name, Key: key, Role: role})
+		}
+
+		*field = apiKeys
+		return nil
+	}
+}
+
+// Route limits are comma separated route=rate:burst entries, e.g. "POST /api/retrievefornotifications=1:5".
+// They replace the default route limits
+func setRateLimits(field *map[string]RateLimit) func(string) error {
+	return func(value string) error {
+		rateLimits := map[string]RateLimit{}
+		for _, entry := range splitList(value) {
+			route, limit, found := strings.Cut(entry, "=")
+			rate, burst, isPair := strings.Cut(limit, ":")
+			if !found || !isPair { return fmt.Errorf("'%s' is not a route=rate:burst entry", entry) }
+
+			parsedRate, rateErr := strconv.ParseFloat(rate, 64)
+			parsedBurst, burstErr := strconv.Atoi(burst)
+			if rateErr != nil || burstErr != nil { return fmt.Errorf("'%s' does not have a numeric rate and an integer burst", entry) }
+
+			rateLimits[strings.TrimSpace(route)] = RateLimit{Rate: parsedRate, Burst: parsedBurst}
+		}
+
+		*field = rateLimits
+		return nil
+	}
+}
+
+func splitList(value string) []string {
+	list := []string{}
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// Validate reports every problem with the configuration at once, so they can all be fixed in one go
+func (cfg Config) Validate() error {
+	errs := []error{}
+	check := func(ok bool, format string, args ...any) {
+		if !ok { errs = append(errs, fmt.Errorf(format, args...)) }
+	}
+
+	check(cfg.Server.Addr != "", "server.addr (LISTEN_ADDR) is required")
+	check(cfg.Server.GRPCAddr != cfg.Server.Addr, "server.grpcAddr (GRPC_ADDR) must differ from server.addr (LISTEN_ADDR)")
+	for _, timeout := range []struct {
+		name  string
+		value time.Duration
+	}{
+		{"server.readHeaderTimeout (SERVER_READ_HEADER_TIMEOUT)", cfg.Server.ReadHeaderTimeout},
+		{"server.readTimeout (SERVER_READ_TIMEOUT)", cfg.Server.ReadTimeout},
+		{"server.writeTimeout (SERVER_WRITE_TIMEOUT)", cfg.Server.WriteTimeout},
+		{"server.idleTimeout (SERVER_IDLE_TIMEOUT)", cfg.Server.IdleTimeout},
+		{"server.shutdownTimeout (SERVER_SHUTDOWN_TIMEOUT)", cfg.Server.ShutdownTimeout},
+		{"database.connectTimeout (DB_CONNECT_TIMEOUT)", cfg.Database.ConnectTimeout},
+	} {
+		check(timeout.value > 0, "%s must be positive, got %s", timeout.name, timeout.value)
+	}
+	check((cfg.Server.TLSCertFile == "") == (cfg.Server.TLSKeyFile == ""),
+		"server.tlsCertFile (SERVER_TLS_CERT_FILE) and server.tlsKeyFile (SERVER_TLS_KEY_FILE) must be set together")
+	for _, tlsFile := range []struct {
+		name string
+		path string
+	}{
+		{"server.tlsCertFile (SERVER_TLS_CERT_FILE)", cfg.Server.TLSCertFile},
+		{"server.tlsKeyFile (SERVER_TLS_KEY_FILE)", cfg.Server.TLSKeyFile},
+	} {
+		if tlsFile.path == "" { continue }
+		_, err := os.Stat(tlsFile.path)
+		check(err == nil, "%s cannot be read: %v", tlsFile.name, err)
+	}
+
+	check(cfg.Database.URL != "", "database.url (DATABASE_URL) is required")
+	check(cfg.Database.MaxConns > 0, "database.maxConns (DB_MAX_CONNS) must be positive, got %d", cfg.Database.MaxConns)
+	check(cfg.Database.MinConns >= 0 && cfg.Database.MinConns <= cfg.Database.MaxConns,
+		"database.minConns (DB_MIN_CONNS) must be between 0 and database.maxConns (%d), got %d", cfg.Database.MaxConns, cfg.Database.MinConns)
+	check(cfg.Database.MaxConnLifetime >= 0, "database.maxConnLifetime (DB_MAX_CONN_LIFETIME) must not be negative")
+	check(cfg.Database.MaxConnIdleTime >= 0, "database.maxConnIdleTime (DB_MAX_CONN_IDLE_TIME) must not be negative")
+
+	for _, origin := range cfg.CORS.AllowedOrigins {
+		check(origin == "*" || strings.HasPrefix(origin, "http://") || strings.HasPrefix(origin, "https://"),
+			"cors.allowedOrigins (CORS_ALLOWED_ORIGINS) must be * or start with http:// or https://, got '%s'", origin)
+	}
+	check(cfg.CORS.MaxAge >= 0, "cors.maxAge (CORS_MAX_AGE) must not be negative")
+
+	if cfg.Auth.Enabled {
+		check(cfg.Auth.JWTSecret != "" || len(cfg.Auth.APIKeys) > 0,
+			"auth.jwtSecret (AUTH_JWT_SECRET) or auth.apiKeys (AUTH_API_KEYS) is required when auth is enabled (set AUTH_ENABLED=false to disable it in development)")
+	}
+	check(cfg.Auth.JWTSecret == "" || len(cfg.Auth.JWTSecret) >= minJWTSecretLength,
+		"auth.jwtSecret (AUTH_JWT_SECRET) must be at least %d characters long", minJWTSecretLength)
+	for index, apiKey := range cfg.Auth.APIKeys {
+		check(apiKey.Name != "" && apiKey.Key != "", "auth.apiKeys[%d] (AUTH_API_KEYS) must have a name and a key", index)
+		check(slices.Contains(apiKeyRoles, apiKey.Role), "auth.apiKeys[%d].role (AUTH_API_KEYS) must be admin or empty, got '%s'", index, apiKey.Role)
+	}
+
+	check(slices.Contains(logLevels, cfg.Log.Level), "log.level (LOG_LEVEL) must be one of %s, got '%s'", strings.Join(logLevels, ", "), cfg.Log.Level)
+	check(slices.Contains(logFormats, cfg.Log.Format), "log.format (LOG_FORMAT) must be one of %s, got '%s'", strings.Join(logFormats, ", "), cfg.Log.Format)
+
+	check(slices.Contains(tracingExporters, cfg.Tracing.Exporter),
+		"tracing.exporter (TRACING_EXPORTER) must be one of %s, got '%s'", strings.Join(tracingExporters, ", "), cfg.Tracing.Exporter)
+	check(cfg.Tracing.Exporter != "otlp" || cfg.Tracing.OTLPEndpoint != "",
+		"tracing.otlpEndpoint (TRACING_OTLP_ENDPOINT) is required when tracing.exporter is otlp")
+	check(cfg.Tracing.OTLPEndpoint == "" || strings.HasPrefix(cfg.Tracing.OTLPEndpoint, "http://") || strings.HasPrefix(cfg.Tracing.OTLPEndpoint, "https://"),
+		"tracing.otlpEndpoint (TRACING_OTLP_ENDPOINT) must start with http:// or https://, got '%s'", cfg.Tracing.OTLPEndpoint)
+	check(cfg.Tracing.ServiceName != "", "tracing.serviceName (TRACING_SERVICE_NAME) is required")
+	check(cfg.Tracing.SampleRatio >= 0 && cfg.Tracing.SampleRatio <= 1,
+		"tracing.sampleRatio (TRACING_SAMPLE_RATIO) must be between 0 and 1, got %g", cfg.Tracing.SampleRatio)
+
+	check(slices.Contains(rateLimitStores, cfg.RateLimit.Store),
+		"rateLimit.store (RATE_LIMIT_STORE) must be one of %s, got '%s'", strings.Join(rateLimitStores, ", "), cfg.RateLimit.Store)
+	check(cfg.RateLimit.Default.Rate > 0 && cfg.RateLimit.Default.Burst >= 1,
+		"rateLimit.default (RATE_LIMIT_RATE, RATE_LIMIT_BURST) must have a positive rate and a burst of at least 1")
+	check(cfg.RateLimit.PerIP.Rate > 0 && cfg.RateLimit.PerIP.Burst >= 1,
+		"rateLimit.perIP (RATE_LIMIT_IP_RATE, RATE_LIMIT_IP_BURST) must have a positive rate and a burst of at least 1")
+	routes := make([]string, 0, len(cfg.RateLimit.Routes))
+	for route := range cfg.RateLimit.Routes {
+		routes = append(routes, route)
+	}
+	slices.Sort(routes) // For a stable error order
+	for _, route := range routes {
+		limit := cfg.RateLimit.Routes[route]
+		method, path, found := strings.Cut(route, " ")
+		check(found && method == strings.ToUpper(method) && strings.HasPrefix(path, "/"),
+			"rateLimit.routes (RATE_LIMIT_ROUTES) keys must look like 'POST /api/register', got '%s'", route)
+		check(limit.Rate > 0 && limit.Burst >= 1,
+			"rateLimit.routes[%s] (RATE_LIMIT_ROUTES) must have a positive rate and a burst of at least 1", route)
+	}
+
+	if cfg.Scheduler.Enabled {
+		check(cfg.Scheduler.Interval > 0, "scheduler.interval (SCHEDULER_INTERVAL) must be positive, got %s", cfg.Scheduler.Interval)
+		check(cfg.Scheduler.BatchSize > 0, "scheduler.batchSize (SCHEDULER_BATCH_SIZE) must be positive, got %d", cfg.Scheduler.BatchSize)
+	}
+
+	return joinErrors("invalid configuration", errs)
+}
+
+func joinErrors(summary string, errs []error) error {
+	if len(errs) == 0 { return nil }
+
+	messages := make([]string, len(errs))
+	for index, err := range errs {
+		messages[index] = "  - " + err.Error()
+	}
+	return fmt.Errorf("%s:\n%s", summary, strings.Join(messages, "\n"))
+}
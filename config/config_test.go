@@ -0,0 +1,129 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadPrecedence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	contents := `
+server:
+  addr: ":9000"
+  readTimeout: 1m
+database:
+  url: "host=yaml"
+  maxConns: 4
+log:
+  level: debug
+`
+	if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+		t.Fatalf("writing config file: %v", err)
+	}
+
+	t.Setenv("CONFIG_FILE", path)
+	t.Setenv("DATABASE_URL", "host=env")
+	t.Setenv("DB_MAX_CONNS", "8")
+	t.Setenv("CORS_ALLOWED_ORIGINS", "https://a.example, https://b.example")
+	t.Setenv("AUTH_API_KEYS", "reporting:s3:cr3t,sis-sync:an0th3r:admin")
+	t.Setenv("RATE_LIMIT_ROUTES", "POST /api/register=0.5:3")
+	t.Setenv("RATE_LIMIT_IP_BURST", "5")
+
+	cfg, args, err := Load([]string{"-database-url", "host=flag", "migrate", "up"})
+	if err != nil {
+		t.Fatalf("loading config: %v", err)
+	}
+
+	checks := []struct {
+		field string
+		got   any
+		want  any
+	}{
+		{"server.addr (YAML over default)", cfg.Server.Addr, ":9000"},
+		{"server.readTimeout (YAML over default)", cfg.Server.ReadTimeout, time.Minute},
+		{"server.writeTimeout (default)", cfg.Server.WriteTimeout, Default().Server.WriteTimeout},
+		{"database.maxConns (env over YAML)", cfg.Database.MaxConns, int32(8)},
+		{"database.url (flag over env)", cfg.Database.URL, "host=flag"},
+		{"cors.allowedOrigins (env list)", strings.Join(cfg.CORS.AllowedOrigins, " "), "https://a.example https://b.example"},
+		{"auth.apiKeys (env name:key pairs)", fmt.Sprint(cfg.Auth.APIKeys), "[{reporting s3:cr3t } {sis-sync an0th3r admin}]"},
+		{"rateLimit.routes (env route=rate:burst entries)", fmt.Sprint(cfg.RateLimit.Routes), "map[POST /api/register:{0.5 3}]"},
+		{"rateLimit.perIP.burst (env over default)", cfg.RateLimit.PerIP.Burst, 5},
+		{"log.level (YAML over default)", cfg.Log.Level, "debug"},
+		{"remaining args", strings.Join(args, " "), "migrate up"},
+	}
+	for _, check := range checks {
+		if check.got != check.want {
+			t.Errorf("wrong %s:\nwant: %v\n got: %v", check.field, check.want, check.got)
+		}
+	}
+}
+
+func TestLoadInvalidEnv(t *testing.T) {
+	t.Setenv("DATABASE_URL", "host=env")
+	t.Setenv("SERVER_READ_TIMEOUT", "fifteen")
+	t.Setenv("AUTH_API_KEYS", "reporting")
+
+	_, _, err := Load([]string{})
+	if err == nil {
+		t.Fatal("expected an error for invalid environment variables")
+	}
+
+	for _, want := range []string{"SERVER_READ_TIMEOUT: 'fifteen' is not a duration", "AUTH_API_KEYS: 'reporting' is not a name:key pair"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error does not mention %q:\n%v", want, err)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	cfg := Default()
+	cfg.Server.ShutdownTimeout = 0
+	cfg.Server.GRPCAddr = cfg.Server.Addr
+	cfg.Database.MinConns = 20
+	cfg.CORS.AllowedOrigins = []string{"example.com"}
+	cfg.Auth.Enabled = true
+	cfg.Log.Format = "xml"
+	cfg.Tracing.Exporter = "otlp"
+	cfg.Tracing.SampleRatio = 2
+	cfg.RateLimit.Routes = map[string]RateLimit{"/api/register": {Rate: 1, Burst: 0}}
+	cfg.Scheduler.Interval = 0
+
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("expected an invalid configuration")
+	}
+
+	for _, want := range []string{
+		"server.shutdownTimeout (SERVER_SHUTDOWN_TIMEOUT) must be positive",
+		"server.grpcAddr (GRPC_ADDR) must differ from server.addr (LISTEN_ADDR)",
+		"database.url (DATABASE_URL) is required",
+		"database.minConns (DB_MIN_CONNS) must be between 0 and database.maxConns (10), got 20",
+		"cors.allowedOrigins (CORS_ALLOWED_ORIGINS) must be * or start with http:// or https://, got 'example.com'",
+		"auth.jwtSecret (AUTH_JWT_SECRET) or auth.apiKeys (AUTH_API_KEYS) is required when auth is enabled",
+		"log.format (LOG_FORMAT) must be one of json, text, got 'xml'",
+		"tracing.otlpEndpoint (TRACING_OTLP_ENDPOINT) is required when tracing.exporter is otlp",
+		"tracing.sampleRatio (TRACING_SAMPLE_RATIO) must be between 0 and 1, got 2",
+		"rateLimit.routes (RATE_LIMIT_ROUTES) keys must look like 'POST /api/register', got '/api/register'",
+		"rateLimit.routes[/api/register] (RATE_LIMIT_ROUTES) must have a positive rate and a burst of at least 1",
+		"scheduler.interval (SCHEDULER_INTERVAL) must be positive, got 0s",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error does not mention %q:\n%v", want, err)
+		}
+	}
+
+	cfg = Default()
+	cfg.Database.URL = "host=localhost"
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "auth.jwtSecret (AUTH_JWT_SECRET) or auth.apiKeys (AUTH_API_KEYS) is required") {
+		t.Errorf("auth should be enabled by default, and so need credentials: %v", err)
+	}
+
+	cfg.Auth.JWTSecret = "0123456789abcdef0123456789abcdef"
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("default configuration with a database url and a JWT secret should be valid: %v", err)
+	}
+}
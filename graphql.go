@@ -0,0 +1,481 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"onecv-go-backend/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+)
+
+// The GraphQL schema is read-only: it lets dashboards walk the teacher/student graph in one request.
+// Fields that fan out (a teacher's students, a student's suspension and teachers) are resolved through
+// per-request batch loaders, so each level of a query costs one database query however many nodes it has.
+// Walking the graph is for administrators: teachers may only read their own students, through notification
+
+// maxGraphQLQueryDepth bounds how far a query may nest, since teachers { students { teachers ... } } has no end
+const maxGraphQLQueryDepth = 5
+
+const graphReadAction = "read the teacher and student graph"
+
+type graphTeacher struct {
+	Email string
+}
+
+type graphStudent struct {
+	Email string
+}
+
+type graphRegistration struct {
+	Teacher graphTeacher
+	Student graphStudent
+}
+
+type graphNotification struct {
+	Teacher graphTeacher
+	Notification string
+	Recipients []graphStudent
+}
+
+type graphLoaders struct {
+	teachers *batchLoader[bool]
+	teacherStudents *batchLoader[[]string]
+	studentTeachers *batchLoader[[]string]
+	suspensions *batchLoader[bool]
+}
+
+func newGraphLoaders() *graphLoaders {
+	return &graphLoaders{
+		teachers: newBatchLoader(models.GetExistingTeachers),
+		teacherStudents: newBatchLoader(models.GetStudentsOfTeachers),
+		studentTeachers: newBatchLoader(models.GetTeachersOfStudents),
+		suspensions: newBatchLoader(models.GetStudentSuspensions),
+	}
+}
+
+type graphQLContextKey struct{}
+
+// graphQLRequest holds what resolvers need from the HTTP request
+type graphQLRequest struct {
+	c *gin.Context
+	loaders *graphLoaders
+}
+
+func graphQLRequestFromContext(ctx context.Context) graphQLRequest {
+	return ctx.Value(graphQLContextKey{}).(graphQLRequest)
+}
+
+// graphQLError carries the same error code and status as the REST API's error responses
+type graphQLError struct {
+	message string
+	code string
+	status int
+}
+
+func (err graphQLError) Error() string { return err.message }
+
+func (err graphQLError) Extensions() map[string]interface{} {
+	return map[string]interface{}{"code": err.code, "status": err.status}
+}
+
+// toGraphQLError hides and logs internal errors, as respondWithError does
+func toGraphQLError(ctx context.Context, err error) error {
+	status, message := getStatusAndMessage(err)
+	code := "internal"
+	if status >= 500 {
+		requestLogger(graphQLRequestFromContext(ctx).c).Error("GraphQL resolver failed", "error", err)
+		message = internalErrorMessage
+	} else {
+		code = errors.Unwrap(err).Error()
+	}
+	return graphQLError{message: message, code: code, status: status}
+}
+
+// emailsArgument normalizes and validates a list of emails passed as an argument
+func emailsArgument(arguments map[string]interface{}, name string) ([]string, error) {
+	emails := []string{}
+	values, _ := arguments[name].([]interface{})
+	for _, value := range values {
+		emails = append(emails, fmt.Sprint(value))
+	}
+	emails = removeDuplicateStr(normalizeEmails(emails))
+
+	if err := checkEmails(emails); err != nil { return nil, err }
+	return emails, nil
+}
+
+func emailArgument(arguments map[string]interface{}, name string) (string, error) {
+	emails, err := emailsArgument(map[string]interface{}{name: []interface{}{arguments[name]}}, name)
+	if err != nil { return "", err }
+	return emails[0], nil
+}
+
+// authorizeGraphQLAdmin is requireAdmin for a GraphQL field
+func authorizeGraphQLAdmin(ctx context.Context) error {
+	authenticated, ok := getPrincipal(graphQLRequestFromContext(ctx).c)
+	if err := authorizeAdmin(authenticated, ok, graphReadAction); err != nil { return toGraphQLError(ctx, err) }
+	return nil
+}
+
+func toGraphStudents(emails []string) []graphStudent {
+	students := make([]graphStudent, len(emails))
+	for index, email := range emails {
+		students[index] = graphStudent{email}
+	}
+	return students
+}
+
+func toGraphTeachers(emails []string) []graphTeacher {
+	teachers := make([]graphTeacher, len(emails))
+	for index, email := range emails {
+		teachers[index] = graphTeacher{email}
+	}
+	return teachers
+}
+
+// loadEmails resolves a field through one of the loaders, e.g. a teacher's students
+func loadEmails[T any](params graphql.ResolveParams, loader *batchLoader[[]string], key string, convert func([]string) []T) (interface{}, error) {
+	thunk := loader.load(params.Context, key)
+	return func() (interface{}, error) {
+		emails, _, err := thunk()
+		if err != nil { return nil, toGraphQLError(params.Context, err) }
+		return convert(emails), nil
+	}, nil
+}
+
+var graphQLSchema = mustBuildGraphQLSchema()
+
+func mustBuildGraphQLSchema() graphql.Schema {
+	nonNullStrings := graphql.NewList(graphql.NewNonNull(graphql.String))
+
+	var teacherType, studentType *graphql.Object
+	teacherType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Teacher",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"email": &graphql.Field{
+					Type: graphql.NewNonNull(graphql.String),
+					Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+						return params.Source.(graphTeacher).Email, nil
+					},
+				},
+				"students": &graphql.Field{
+					Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(studentType))),
+					Description: "The students registered to the teacher, in alphabetical order",
+					Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+						request, teacher := graphQLRequestFromContext(params.Context), params.Source.(graphTeacher).Email
+						if err := authorizeTeacher(request.c, teacher, "read students"); err != nil {
+							return nil, toGraphQLError(params.Context, err)
+						}
+						return loadEmails(params, request.loaders.teacherStudents, teacher, toGraphStudents)
+					},
+				},
+			}
+		}),
+	})
+
+	studentType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Student",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"email": &graphql.Field{
+					Type: graphql.NewNonNull(graphql.String),
+					Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+						return params.Source.(graphStudent).Email, nil
+					},
+				},
+				"suspended": &graphql.Field{
+					Type: graphql.NewNonNull(graphql.Boolean),
+					Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+						loaders := graphQLRequestFromContext(params.Context).loaders
+						thunk := loaders.suspensions.load(params.Context, params.Source.(graphStudent).Email)
+						return func() (interface{}, error) {
+							suspended, _, err := thunk()
+							if err != nil { return nil, toGraphQLError(params.Context, err) }
+							return suspended, nil
+						}, nil
+					},
+				},
+				"teachers": &graphql.Field{
+					Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(teacherType))),
+					Description: "The teachers the student is registered to, in alphabetical order",
+					Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+						if err := authorizeGraphQLAdmin(params.Context); err != nil { return nil, err }
+
+						loaders := graphQLRequestFromContext(params.Context).loaders
+						return loadEmails(params, loaders.studentTeachers, params.Source.(graphStudent).Email, toGraphTeachers)
+					},
+				},
+			}
+		}),
+	})
+
+	registrationType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Registration",
+		Fields: graphql.Fields{
+			"teacher": &graphql.Field{
+				Type: graphql.NewNonNull(teacherType),
+				Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+					return params.Source.(graphRegistration).Teacher, nil
+				},
+			},
+			"student": &graphql.Field{
+				Type: graphql.NewNonNull(studentType),
+				Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+					return params.Source.(graphRegistration).Student, nil
+				},
+			},
+		},
+	})
+
+	notificationType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Notification",
+		Fields: graphql.Fields{
+			"teacher": &graphql.Field{
+				Type: graphql.NewNonNull(teacherType),
+				Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+					return params.Source.(graphNotification).Teacher, nil
+				},
+			},
+			"notification": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.String),
+				Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+					return params.Source.(graphNotification).Notification, nil
+				},
+			},
+			"recipients": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(studentType))),
+				Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+					return params.Source.(graphNotification).Recipients, nil
+				},
+			},
+		},
+	})
+
+	queryType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"teacher": &graphql.Field{
+				Type: teacherType,
+				Description: "A teacher, or null if there is none with this email",
+				Args: graphql.FieldConfigArgument{"email": {Type: graphql.NewNonNull(graphql.String)}},
+				Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+					if err := authorizeGraphQLAdmin(params.Context); err != nil { return nil, err }
+
+					teacher, err := emailArgument(params.Args, "email")
+					if err != nil { return nil, toGraphQLError(params.Context, err) }
+
+					thunk := graphQLRequestFromContext(params.Context).loaders.teachers.load(params.Context, teacher)
+					return func() (interface{}, error) {
+						_, found, err := thunk()
+						if err != nil || !found { return nil, errorOrNil(params.Context, err) }
+						return graphTeacher{teacher}, nil
+					}, nil
+				},
+			},
+			"teachers": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(teacherType))),
+				Description: "The teachers with the given emails, or every teacher if none are given",
+				Args: graphql.FieldConfigArgument{"emails": {Type: nonNullStrings}},
+				Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+					if err := authorizeGraphQLAdmin(params.Context); err != nil { return nil, err }
+
+					if _, given := params.Args["emails"]; !given {
+						teachers, err := models.ListTeachers(params.Context)
+						if err != nil { return nil, toGraphQLError(params.Context, err) }
+						return toGraphTeachers(teachers), nil
+					}
+
+					emails, err := emailsArgument(params.Args, "emails")
+					if err != nil { return nil, toGraphQLError(params.Context, err) }
+
+					existingTeachers, err := models.GetExistingTeachers(params.Context, emails)
+					if err != nil { return nil, toGraphQLError(params.Context, err) }
+
+					teachers := []graphTeacher{}
+					for _, email := range emails {
+						if existingTeachers[email] {
+							teachers = append(teachers, graphTeacher{email})
+						}
+					}
+					return teachers, nil
+				},
+			},
+			"student": &graphql.Field{
+				Type: studentType,
+				Description: "A student, or null if there is none with this email",
+				Args: graphql.FieldConfigArgument{"email": {Type: graphql.NewNonNull(graphql.String)}},
+				Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+					if err := authorizeGraphQLAdmin(params.Context); err != nil { return nil, err }
+
+					student, err := emailArgument(params.Args, "email")
+					if err != nil { return nil, toGraphQLError(params.Context, err) }
+
+					thunk := graphQLRequestFromContext(params.Context).loaders.suspensions.load(params.Context, student)
+					return func() (interface{}, error) {
+						_, found, err := thunk()
+						if err != nil || !found { return nil, errorOrNil(params.Context, err) }
+						return graphStudent{student}, nil
+					}, nil
+				},
+			},
+			"commonStudents": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(studentType))),
+				Description: "The students registered to every given teacher",
+				Args: graphql.FieldConfigArgument{"teachers": {Type: graphql.NewNonNull(nonNullStrings)}},
+				Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+					if err := authorizeGraphQLAdmin(params.Context); err != nil { return nil, err }
+
+					teachers, err := emailsArgument(params.Args, "teachers")
+					if err != nil { return nil, toGraphQLError(params.Context, err) }
+
+					students, err := models.GetCommonStudents(params.Context, teachers)
+					if err != nil { return nil, toGraphQLError(params.Context, err) }
+					return toGraphStudents(students), nil
+				},
+			},
+			"registrations": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(registrationType))),
+				Description: "The registrations of the given teachers",
+				Args: graphql.FieldConfigArgument{"teachers": {Type: graphql.NewNonNull(nonNullStrings)}},
+				Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+					if err := authorizeGraphQLAdmin(params.Context); err != nil { return nil, err }
+
+					teachers, err := emailsArgument(params.Args, "teachers")
+					if err != nil { return nil, toGraphQLError(params.Context, err) }
+
+					teacherStudents, err := models.GetStudentsOfTeachers(params.Context, teachers)
+					if err != nil { return nil, toGraphQLError(params.Context, err) }
+
+					registrations := []graphRegistration{}
+					for _, teacher := range teachers {
+						for _, student := range teacherStudents[teacher] {
+							registrations = append(registrations, graphRegistration{graphTeacher{teacher}, graphStudent{student}})
+						}
+					}
+					return registrations, nil
+				},
+			},
+			"notification": &graphql.Field{
+				Type: graphql.NewNonNull(notificationType),
+				Description: "The students who would receive a notification from a teacher",
+				Args: graphql.FieldConfigArgument{
+					"teacher": {Type: graphql.NewNonNull(graphql.String)},
+					"notification": {Type: graphql.NewNonNull(graphql.String)},
+				},
+				Resolve: func(params graphql.ResolveParams) (interface{}, error) {
+					notification := fmt.Sprint(params.Args["notification"])
+					processedData, err := prepareNotification(models.RetrieveForNotificationsData{Teacher: fmt.Sprint(params.Args["teacher"]), Notification: notification})
+					if err != nil { return nil, toGraphQLError(params.Context, err) }
+
+					teacher := processedData.Teacher
+					if err := authorizeTeacher(graphQLRequestFromContext(params.Context).c, teacher, "send notifications"); err != nil {
+						return nil, toGraphQLError(params.Context, err)
+					}
+
+					recipients, err := models.GetNotificationRecipients(params.Context, processedData)
+					if err != nil { return nil, toGraphQLError(params.Context, err) }
+
+					return graphNotification{graphTeacher{teacher}, notification, toGraphStudents(recipients)}, nil
+				},
+			},
+		},
+	})
+
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{Query: queryType})
+	if err != nil {
+		panic(fmt.Sprintf("building the GraphQL schema: %v", err))
+	}
+	return schema
+}
+
+func errorOrNil(ctx context.Context, err error) error {
+	if err == nil { return nil }
+	return toGraphQLError(ctx, err)
+}
+
+type graphQLRequestBody struct {
+	Query string `json:"query" binding:"required"`
+	OperationName string `json:"operationName"`
+	Variables map[string]interface{} `json:"variables"`
+}
+
+// postGraphQL answers 200 whenever the query could be run, with any errors in the result's errors field
+func postGraphQL(c *gin.Context) {
+	var requestBody graphQLRequestBody
+	if err := c.BindJSON(&requestBody); err != nil {
+		err := fmt.Errorf(customErrors["invalidDataType"].Message, errors.New("invalidDataType"))
+		respondWithError(c, err)
+		return
+	}
+
+	if document, err := parser.Parse(parser.ParseParams{Source: requestBody.Query}); err == nil {
+		// Queries that do not parse are left to graphql.Do, which reports them in the result
+		if depth := queryDepth(document); depth > maxGraphQLQueryDepth {
+			respondWithError(c, fmt.Errorf(customErrors["queryTooDeep"].Message, errors.New("queryTooDeep"), maxGraphQLQueryDepth, depth))
+			return
+		}
+	}
+
+	ctx := context.WithValue(c.Request.Context(), graphQLContextKey{}, graphQLRequest{c: c, loaders: newGraphLoaders()})
+	result := graphql.Do(graphql.Params{
+		Schema: graphQLSchema,
+		RequestString: requestBody.Query,
+		OperationName: requestBody.OperationName,
+		VariableValues: requestBody.Variables,
+		Context: ctx,
+	})
+
+	c.JSON(http.StatusOK, result)
+}
+
+// queryDepth is how many fields deep the deepest operation in document nests, counting through fragments
+func queryDepth(document *ast.Document) int {
+	fragments := map[string]*ast.FragmentDefinition{}
+	for _, definition := range document.Definitions {
+		if fragment, ok := definition.(*ast.FragmentDefinition); ok {
+			fragments[fragment.Name.Value] = fragment
+		}
+	}
+
+	// Fragments that spread themselves are rejected by validation, so visiting guards only against looping here
+	visiting := map[string]bool{}
+	var selectionSetDepth func(selectionSet *ast.SelectionSet) int
+	selectionSetDepth = func(selectionSet *ast.SelectionSet) int {
+		if selectionSet == nil { return 0 }
+
+		depth := 0
+		for _, selection := range selectionSet.Selections {
+			selectionDepth := 0
+			switch selection := selection.(type) {
+			case *ast.Field:
+				selectionDepth = 1 + selectionSetDepth(selection.SelectionSet)
+			case *ast.InlineFragment:
+				selectionDepth = selectionSetDepth(selection.SelectionSet)
+			case *ast.FragmentSpread:
+				fragment, found := fragments[selection.Name.Value]
+				if found && !visiting[fragment.Name.Value] {
+					visiting[fragment.Name.Value] = true
+					selectionDepth = selectionSetDepth(fragment.SelectionSet)
+					delete(visiting, fragment.Name.Value)
+				}
+			}
+			depth = max(depth, selectionDepth)
+		}
+		return depth
+	}
+
+	depth := 0
+	for _, definition := range document.Definitions {
+		if operation, ok := definition.(*ast.OperationDefinition); ok {
+			depth = max(depth, selectionSetDepth(operation.SelectionSet))
+		}
+	}
+	return depth
+}
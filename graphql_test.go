@@ -0,0 +1,170 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"onecv-go-backend/models"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/pashagolub/pgxmock/v3"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+func graphQLTestCase(testCaseDesc string, query string, addExpectedQueries func(mock pgxmock.PgxConnIface), wantResponseBody any) routeTestCase {
+	return routeTestCase{testCaseDesc, "POST", "/graphql", map[string]any{"query": query}, addExpectedQueries, 200, wantResponseBody}
+}
+
+func TestGraphQL(t *testing.T) {
+	testCases := []routeTestCase{
+		graphQLTestCase(
+			"Nested fields take one query per level",
+			"{ teachers { email students { email suspended teachers { email } } } }",
+			func(mock pgxmock.PgxConnIface) {
+				mock.MatchExpectationsInOrder(false)
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT email FROM teacher ORDER BY email")).
+					WillReturnRows(pgxmock.NewRows([]string{"email"}).AddRow("ann@gmail.com").AddRow("tom@gmail.com"))
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT teacher, student FROM teacher_student_relationship WHERE teacher = ANY($1) ORDER BY student")).
+					WithArgs([]string{"ann@gmail.com", "tom@gmail.com"}).
+					WillReturnRows(pgxmock.NewRows([]string{"teacher", "student"}).
+						AddRow("ann@gmail.com", "jerry@gmail.com").AddRow("tom@gmail.com", "jerry@gmail.com").AddRow("tom@gmail.com", "spike@gmail.com"))
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT email, COALESCE(suspended, false) FROM student WHERE email = ANY($1)")).
+					WithArgs([]string{"jerry@gmail.com", "spike@gmail.com"}).
+					WillReturnRows(pgxmock.NewRows([]string{"email", "suspended"}).AddRow("jerry@gmail.com", false).AddRow("spike@gmail.com", true))
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT student, teacher FROM teacher_student_relationship WHERE student = ANY($1) ORDER BY teacher")).
+					WithArgs([]string{"jerry@gmail.com", "spike@gmail.com"}).
+					WillReturnRows(pgxmock.NewRows([]string{"student", "teacher"}).
+						AddRow("jerry@gmail.com", "ann@gmail.com").AddRow("jerry@gmail.com", "tom@gmail.com").AddRow("spike@gmail.com", "tom@gmail.com"))
+			},
+			map[string]any{"data": map[string]any{"teachers": []any{
+				map[string]any{"email": "ann@gmail.com", "students": []any{
+					map[string]any{"email": "jerry@gmail.com", "suspended": false, "teachers": []any{map[string]any{"email": "ann@gmail.com"}, map[string]any{"email": "tom@gmail.com"}}},
+				}},
+				map[string]any{"email": "tom@gmail.com", "students": []any{
+					map[string]any{"email": "jerry@gmail.com", "suspended": false, "teachers": []any{map[string]any{"email": "ann@gmail.com"}, map[string]any{"email": "tom@gmail.com"}}},
+					map[string]any{"email": "spike@gmail.com", "suspended": true, "teachers": []any{map[string]any{"email": "tom@gmail.com"}}},
+				}},
+			}}},
+		),
+		graphQLTestCase(
+			"A teacher that does not exist is null",
+			`{ teacher(email: "Nobody@Gmail.com") { email } }`,
+			func(mock pgxmock.PgxConnIface) {
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT email FROM teacher WHERE email = ANY($1)")).WithArgs([]string{"nobody@gmail.com"}).
+					WillReturnRows(pgxmock.NewRows([]string{"email"}))
+			},
+			map[string]any{"data": map[string]any{"teacher": nil}},
+		),
+		graphQLTestCase(
+			"An invalid email is reported with its error code",
+			`{ student(email: "jerrygmail.com") { email } }`,
+			nil,
+			map[string]any{
+				"data": map[string]any{"student": nil},
+				"errors": []any{map[string]any{
+					"message": fmt.Errorf(customErrors["invalidEmail"].Message, errors.New("invalidEmail"), "'jerrygmail.com'").Error(),
+					"locations": []any{map[string]any{"line": float64(1), "column": float64(3)}},
+					"path": []any{"student"},
+					"extensions": map[string]any{"code": "invalidEmail", "status": float64(400)},
+				}},
+			},
+		),
+		{
+			"Queries nested too deeply are rejected before they run",
+			"POST", "/graphql",
+			map[string]any{"query": "{ teachers { students { teachers { students { teachers { email } } } } } }"},
+			nil,
+			customErrors["queryTooDeep"].Status,
+			errorResponseBody{Message: fmt.Errorf(customErrors["queryTooDeep"].Message, errors.New("queryTooDeep"), maxGraphQLQueryDepth, 6).Error()},
+		},
+		{
+			"Fragments count towards the depth of a query",
+			"POST", "/graphql",
+			map[string]any{"query": "{ teachers { ...deep } } fragment deep on Teacher { students { teachers { students { teachers { email } } } } }"},
+			nil,
+			customErrors["queryTooDeep"].Status,
+			errorResponseBody{Message: fmt.Errorf(customErrors["queryTooDeep"].Message, errors.New("queryTooDeep"), maxGraphQLQueryDepth, 6).Error()},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.testCaseDesc, func(t *testing.T) {
+			runRouteTest(t, testRouter, testCase)
+		})
+	}
+}
+
+func TestGraphQLAuthorization(t *testing.T) {
+	cfg := testAuthConfig()
+	authRouter := router(cfg, noop.NewTracerProvider(), newRateLimitStore(cfg.RateLimit))
+
+	bearer := func(subject string, role string) string {
+		claims := tokenClaims{Role: role, RegisteredClaims: jwt.RegisteredClaims{Subject: subject, Issuer: "onecv-test", ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour))}}
+		return "Bearer " + signTestToken(t, claims, testJWTSecret)
+	}
+
+	// Fields that pass authorization reach the stub database, which has no expectations and so fails as internal
+	testCases := []struct {
+		testCaseDesc string
+		query string
+		authorization string
+		wantCode string
+	}{
+		{"Teacher lists teachers", "{ teachers { email } }", bearer("tom@gmail.com", teacherRole), "adminRoleRequired"},
+		{"Teacher reads a teacher", `{ teacher(email: "tom@gmail.com") { email } }`, bearer("tom@gmail.com", teacherRole), "adminRoleRequired"},
+		{"Teacher reads a student", `{ student(email: "jerry@gmail.com") { email } }`, bearer("tom@gmail.com", teacherRole), "adminRoleRequired"},
+		{"Teacher reads common students", `{ commonStudents(teachers: ["tom@gmail.com"]) { email } }`, bearer("tom@gmail.com", teacherRole), "adminRoleRequired"},
+		{"Teacher reads registrations", `{ registrations(teachers: ["tom@gmail.com"]) { student { email } } }`, bearer("tom@gmail.com", teacherRole), "adminRoleRequired"},
+		{"Teacher previews a notification as another teacher", `{ notification(teacher: "quacker@gmail.com", notification: "Hello") { teacher { email } } }`, bearer("tom@gmail.com", teacherRole), "notYourself"},
+		{"Teacher previews a notification as themselves", `{ notification(teacher: "tom@gmail.com", notification: "Hello") { teacher { email } } }`, bearer("tom@gmail.com", teacherRole), "internal"},
+		{"Admin reads registrations", `{ registrations(teachers: ["tom@gmail.com"]) { student { email } } }`, bearer("principal@gmail.com", adminRole), "internal"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testCaseDesc, func(t *testing.T) {
+			mock, err := pgxmock.NewConn()
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer mock.Close(context.Background())
+			models.DB = mock
+
+			body, err := json.Marshal(map[string]any{"query": tc.query})
+			if err != nil {
+				t.Fatalf("encoding body: %v", err)
+			}
+
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest("POST", "/graphql", bytes.NewReader(body))
+			if err != nil {
+				t.Fatalf("building request: %v", err)
+			}
+			request.Header.Set("Authorization", tc.authorization)
+
+			authRouter.ServeHTTP(recorder, request)
+
+			if recorder.Code != 200 {
+				t.Fatalf("wrong status code:\nwant: %d\n got: %d (%s)", 200, recorder.Code, recorder.Body.String())
+			}
+
+			var result struct {
+				Errors []struct {
+					Extensions map[string]any `json:"extensions"`
+				} `json:"errors"`
+			}
+			if err := json.Unmarshal(recorder.Body.Bytes(), &result); err != nil {
+				t.Fatalf("decoding response: %v", err)
+			}
+			if len(result.Errors) != 1 || result.Errors[0].Extensions["code"] != tc.wantCode {
+				t.Errorf("wrong errors:\nwant one with code %q\n got: %s", tc.wantCode, recorder.Body.String())
+			}
+		})
+	}
+}
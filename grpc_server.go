@@ -0,0 +1,372 @@
+package main
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative onecvpb/onecv.proto
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"onecv-go-backend/config"
+	"onecv-go-backend/models"
+	"onecv-go-backend/onecvpb"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// grpcServer serves the operations of the HTTP API to internal services. It validates requests and
+// calls the models in the same way as the HTTP handlers, so that both transports behave alike
+type grpcServer struct {
+	onecvpb.UnimplementedOneCVServer
+}
+
+type grpcPrincipalContextKey struct{}
+
+type grpcAuditTargetsContextKey struct{}
+
+// grpcRoutes gives each method the HTTP route it mirrors, so that both transports share its rate limit
+var grpcRoutes = map[string]string{
+	onecvpb.OneCV_RegisterStudents_FullMethodName: "POST /api/register",
+	onecvpb.OneCV_GetCommonStudents_FullMethodName: "GET /api/commonstudents",
+	onecvpb.OneCV_SuspendStudent_FullMethodName: "POST /api/suspend",
+	onecvpb.OneCV_RetrieveForNotifications_FullMethodName: "POST /api/retrievefornotifications",
+}
+
+// Mutating methods are audited under the same actions as their HTTP routes
+var grpcAuditActions = map[string]string{
+	onecvpb.OneCV_RegisterStudents_FullMethodName: "register",
+	onecvpb.OneCV_SuspendStudent_FullMethodName: "suspend",
+	onecvpb.OneCV_RetrieveForNotifications_FullMethodName: "notify",
+}
+
+// newGRPCServer applies the same middleware as the HTTP API. rateLimitStore should be the one the HTTP API uses,
+// so that a client's limits hold across both transports
+func newGRPCServer(cfg config.Config, rateLimitStore rateLimitStore) (*grpc.Server, error) {
+	options := []grpc.ServerOption{grpc.ChainUnaryInterceptor(
+		logGRPCCalls(), recordGRPCMetrics(), rateLimitGRPCByIP(cfg.RateLimit, rateLimitStore), authenticateGRPC(cfg.Auth),
+		rateLimitGRPC(cfg.RateLimit, rateLimitStore), auditGRPC(),
+	)}
+	if cfg.Server.TLSCertFile != "" {
+		tlsCredentials, err := credentials.NewServerTLSFromFile(cfg.Server.TLSCertFile, cfg.Server.TLSKeyFile)
+		if err != nil { return nil, err }
+
+		options = append(options, grpc.Creds(tlsCredentials))
+	}
+
+	server := grpc.NewServer(options...)
+	onecvpb.RegisterOneCVServer(server, grpcServer{})
+	return server, nil
+}
+
+// serveGRPC serves until ctx is cancelled, then gives in-flight calls up to the shutdown timeout to finish
+func serveGRPC(ctx context.Context, server *grpc.Server, listener net.Listener, shutdownTimeout time.Duration) error {
+	serverErrors := make(chan error, 1)
+	go func() {
+		slog.Info("Listening for gRPC", "addr", listener.Addr().String())
+		serverErrors <- server.Serve(listener)
+	}()
+
+	select {
+	case err := <-serverErrors:
+		return err
+	case <-ctx.Done():
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		server.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-time.After(shutdownTimeout):
+		server.Stop()
+	}
+	return nil
+}
+
+// authenticateGRPC reads credentials from the x-api-key and authorization metadata, like the
+// X-API-Key and Authorization headers over HTTP. It lets every call through when auth is disabled
+func authenticateGRPC(cfg config.AuthConfig) grpc.UnaryServerInterceptor {
+	if !cfg.Enabled {
+		return func(ctx context.Context, request any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+			return handler(ctx, request)
+		}
+	}
+
+	authenticateCredentials := newAuthenticator(cfg)
+	return func(ctx context.Context, request any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		firstValue := func(key string) string {
+			if values := md.Get(key); len(values) > 0 {
+				return values[0]
+			}
+			return ""
+		}
+
+		authenticated, err := authenticateCredentials(firstValue(strings.ToLower(apiKeyHeader)), firstValue("authorization"))
+		if err != nil { return nil, toGRPCError(ctx, err) }
+
+		return handler(context.WithValue(ctx, grpcPrincipalContextKey{}, authenticated), request)
+	}
+}
+
+// getGRPCPrincipal returns the caller a call was authenticated as. ok is false when auth is disabled
+func getGRPCPrincipal(ctx context.Context) (authenticated principal, ok bool) {
+	authenticated, ok = ctx.Value(grpcPrincipalContextKey{}).(principal)
+	return authenticated, ok
+}
+
+// rateLimitGRPC takes a token from the bucket of the HTTP route a method mirrors, answering ResourceExhausted with
+// a retry-after header once it is empty. Like over HTTP, calls are let through if the store fails
+func rateLimitGRPC(cfg config.RateLimitConfig, store rateLimitStore) grpc.UnaryServerInterceptor {
+	if !cfg.Enabled {
+		return func(ctx context.Context, request any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+			return handler(ctx, request)
+		}
+	}
+
+	return func(ctx context.Context, request any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		route, mirrored := grpcRoutes[info.FullMethod]
+		if !mirrored {
+			route = info.FullMethod
+		}
+		limit, hasRouteLimit := cfg.Routes[route]
+		if !hasRouteLimit {
+			limit = cfg.Default
+		}
+
+		authenticated, ok := getGRPCPrincipal(ctx)
+		if err := applyGRPCRateLimit(ctx, store, route, rateLimitKey(authenticated, ok, grpcClientIP(ctx)), limit); err != nil {
+			return nil, err
+		}
+		return handler(ctx, request)
+	}
+}
+
+// rateLimitGRPCByIP shares the per-IP bucket of the HTTP API, and like rateLimitIP runs before authentication
+func rateLimitGRPCByIP(cfg config.RateLimitConfig, store rateLimitStore) grpc.UnaryServerInterceptor {
+	if !cfg.Enabled {
+		return func(ctx context.Context, request any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+			return handler(ctx, request)
+		}
+	}
+
+	return func(ctx context.Context, request any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if err := applyGRPCRateLimit(ctx, store, perIPRoute, "ip:"+grpcClientIP(ctx), cfg.PerIP); err != nil {
+			return nil, err
+		}
+		return handler(ctx, request)
+	}
+}
+
+// applyGRPCRateLimit returns the error to answer with when the bucket is empty
+func applyGRPCRateLimit(ctx context.Context, store rateLimitStore, route string, client string, limit config.RateLimit) error {
+	allowed, retryAfter, err := store.take(ctx, route+"|"+client, limit)
+	if err != nil {
+		slog.ErrorContext(ctx, "Unable to apply the rate limit", "route", route, "error", err)
+		return nil
+	}
+
+	if !allowed {
+		seconds := int(math.Ceil(retryAfter.Seconds()))
+		grpc.SetHeader(ctx, metadata.Pairs("retry-after", strconv.Itoa(seconds)))
+		return toGRPCError(ctx, fmt.Errorf(customErrors["rateLimited"].Message, errors.New("rateLimited"), seconds))
+	}
+	return nil
+}
+
+func grpcClientIP(ctx context.Context) string {
+	callPeer, ok := peer.FromContext(ctx)
+	if !ok { return "" }
+
+	host, _, err := net.SplitHostPort(callPeer.Addr.String())
+	if err != nil { return callPeer.Addr.String() }
+	return host
+}
+
+// auditGRPC records an audit event for every call to a mutating method once it has been handled, like audit does
+// over HTTP. The payload hash is of the request's protobuf encoding, and the status is the HTTP status matching the
+// call's code. Methods name the emails the call was about with setGRPCAuditTargets
+func auditGRPC() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, request any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		action, audited := grpcAuditActions[info.FullMethod]
+		if !audited { return handler(ctx, request) }
+
+		var payload []byte
+		if message, ok := request.(proto.Message); ok {
+			payload, _ = proto.MarshalOptions{Deterministic: true}.Marshal(message)
+		}
+		payloadHash := sha256.Sum256(payload)
+
+		// Taken from the x-request-id metadata or generated, like over HTTP
+		md, _ := metadata.FromIncomingContext(ctx)
+		requestID := ""
+		if values := md.Get(strings.ToLower(requestIDHeader)); len(values) > 0 {
+			requestID = values[0]
+		}
+		if !validRequestID.MatchString(requestID) {
+			requestID = newRequestID()
+		}
+		grpc.SetHeader(ctx, metadata.Pairs(strings.ToLower(requestIDHeader), requestID))
+
+		targets := []string{}
+		response, err := handler(context.WithValue(ctx, grpcAuditTargetsContextKey{}, &targets), request)
+
+		actor := anonymousActor
+		if authenticated, ok := getGRPCPrincipal(ctx); ok {
+			actor = authenticated.Subject
+		}
+
+		httpStatus := grpcHTTPStatus(status.Code(err))
+		event := models.AuditEvent{
+			Actor: actor,
+			Action: action,
+			TargetEmails: targets,
+			TargetIDs: []string{},
+			PayloadHash: hex.EncodeToString(payloadHash[:]),
+			Outcome: auditOutcome(httpStatus),
+			Status: httpStatus,
+			RequestID: requestID,
+		}
+
+		// The event is still recorded if the client has gone away
+		if err := models.RecordAuditEvent(context.WithoutCancel(ctx), event); err != nil {
+			slog.ErrorContext(ctx, "Unable to record audit event", "action", action, "requestId", requestID, "error", err)
+		}
+		return response, err
+	}
+}
+
+func setGRPCAuditTargets(ctx context.Context, emails ...string) {
+	if targets, ok := ctx.Value(grpcAuditTargetsContextKey{}).(*[]string); ok {
+		*targets = emails
+	}
+}
+
+func logGRPCCalls() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, request any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+		response, err := handler(ctx, request)
+
+		code := status.Code(err)
+		level := slog.LevelInfo
+		if code == codes.Internal || code == codes.Unknown {
+			level = slog.LevelError
+		} else if err != nil {
+			level = slog.LevelWarn
+		}
+
+		slog.Default().Log(ctx, level, "Handled gRPC call",
+			"method", info.FullMethod,
+			"code", code.String(),
+			"durationMs", float64(time.Since(start).Microseconds())/1000,
+		)
+		return response, err
+	}
+}
+
+var grpcCodes = map[int]codes.Code{
+	http.StatusBadRequest: codes.InvalidArgument,
+	http.StatusUnauthorized: codes.Unauthenticated,
+	http.StatusForbidden: codes.PermissionDenied,
+	http.StatusNotFound: codes.NotFound,
+	http.StatusConflict: codes.AlreadyExists,
+	http.StatusTooManyRequests: codes.ResourceExhausted,
+}
+
+// grpcHTTPStatus is the inverse of grpcCodes. Unmapped codes are internal errors, as in toGRPCError
+func grpcHTTPStatus(code codes.Code) int {
+	if code == codes.OK { return http.StatusOK }
+
+	for httpStatus, grpcCode := range grpcCodes {
+		if grpcCode == code { return httpStatus }
+	}
+	return http.StatusInternalServerError
+}
+
+// toGRPCError gives err the gRPC code matching its HTTP status. Like respondWithError, it logs
+// internal errors rather than returning them, as they may reveal implementation details
+func toGRPCError(ctx context.Context, err error) error {
+	httpStatus, message := getStatusAndMessage(err)
+
+	code, mapped := grpcCodes[httpStatus]
+	if !mapped {
+		slog.Default().ErrorContext(ctx, "gRPC call failed", "error", err)
+		return status.Error(codes.Internal, internalErrorMessage)
+	}
+
+	return status.Error(code, message)
+}
+
+func (grpcServer) RegisterStudents(ctx context.Context, request *onecvpb.RegisterStudentsRequest) (*onecvpb.RegisterStudentsResponse, error) {
+	studentRegistrationData, err := prepareStudentRegistration(models.StudentRegistrationData[string]{Teacher: request.Teacher, Students: request.Students})
+	setGRPCAuditTargets(ctx, append([]string{studentRegistrationData.Teacher}, studentRegistrationData.Students...)...)
+	if err != nil { return nil, toGRPCError(ctx, err) }
+
+	authenticated, ok := getGRPCPrincipal(ctx)
+	if err := authorizeTeacherAs(authenticated, ok, studentRegistrationData.Teacher, "register students"); err != nil {
+		return nil, toGRPCError(ctx, err)
+	}
+
+	if err := models.RegisterStudents(ctx, studentRegistrationData); err != nil { return nil, toGRPCError(ctx, err) }
+
+	return &onecvpb.RegisterStudentsResponse{}, nil
+}
+
+func (grpcServer) GetCommonStudents(ctx context.Context, request *onecvpb.GetCommonStudentsRequest) (*onecvpb.GetCommonStudentsResponse, error) {
+	teachers := removeDuplicateStr(normalizeEmails(request.Teachers))
+	if err := checkEmails(teachers); err != nil { return nil, toGRPCError(ctx, err) }
+
+	commonStudents, err := models.GetCommonStudents(ctx, teachers)
+	if err != nil { return nil, toGRPCError(ctx, err) }
+
+	return &onecvpb.GetCommonStudentsResponse{Students: commonStudents}, nil
+}
+
+func (grpcServer) SuspendStudent(ctx context.Context, request *onecvpb.SuspendStudentRequest) (*onecvpb.SuspendStudentResponse, error) {
+	authenticated, ok := getGRPCPrincipal(ctx)
+	if err := authorizeAdmin(authenticated, ok, "suspend students"); err != nil { return nil, toGRPCError(ctx, err) }
+
+	student := normalizeEmail(request.Student)
+	setGRPCAuditTargets(ctx, student)
+	if err := checkEmails([]string{student}); err != nil { return nil, toGRPCError(ctx, err) }
+
+	if err := models.SuspendStudent(ctx, models.StudentSuspensionData[string]{Student: student}); err != nil {
+		return nil, toGRPCError(ctx, err)
+	}
+
+	return &onecvpb.SuspendStudentResponse{}, nil
+}
+
+func (grpcServer) RetrieveForNotifications(ctx context.Context, request *onecvpb.RetrieveForNotificationsRequest) (*onecvpb.RetrieveForNotificationsResponse, error) {
+	retrieveForNotificationsProcessedData, err := prepareNotification(models.RetrieveForNotificationsData{Teacher: request.Teacher, Notification: request.Notification})
+	setGRPCAuditTargets(ctx, append([]string{retrieveForNotificationsProcessedData.Teacher}, retrieveForNotificationsProcessedData.Students...)...)
+	if err != nil { return nil, toGRPCError(ctx, err) }
+
+	authenticated, ok := getGRPCPrincipal(ctx)
+	if err := authorizeTeacherAs(authenticated, ok, retrieveForNotificationsProcessedData.Teacher, "send notifications"); err != nil {
+		return nil, toGRPCError(ctx, err)
+	}
+
+	recipients, err := models.RetrieveForNotifications(ctx, retrieveForNotificationsProcessedData)
+	if err != nil { return nil, toGRPCError(ctx, err) }
+
+	return &onecvpb.RetrieveForNotificationsResponse{Recipients: recipients}, nil
+}
@@ -0,0 +1,248 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"regexp"
+	"testing"
+	"time"
+
+	"onecv-go-backend/config"
+	"onecv-go-backend/models"
+	"onecv-go-backend/onecvpb"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/pashagolub/pgxmock/v3"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+)
+
+// newTestGRPCClient serves the gRPC service in memory and returns a client for it
+func newTestGRPCClient(t *testing.T, cfg config.Config) onecvpb.OneCVClient {
+	server, err := newGRPCServer(cfg, newRateLimitStore(cfg.RateLimit))
+	if err != nil {
+		t.Fatalf("creating gRPC server: %v", err)
+	}
+
+	listener := bufconn.Listen(1024 * 1024)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	connection, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return listener.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("dialing gRPC server: %v", err)
+	}
+	t.Cleanup(func() { connection.Close() })
+
+	return onecvpb.NewOneCVClient(connection)
+}
+
+func TestGRPCServer(t *testing.T) {
+	testCases := []struct {
+		testCaseDesc string
+		cfg config.Config
+		metadata map[string]string
+		call func(ctx context.Context, client onecvpb.OneCVClient) error
+		addExpectedQueries func(mock pgxmock.PgxConnIface)
+		wantCode codes.Code
+		wantMessage string
+	}{
+		{
+			"Register students",
+			testConfig(), nil,
+			func(ctx context.Context, client onecvpb.OneCVClient) error {
+				_, err := client.RegisterStudents(ctx, &onecvpb.RegisterStudentsRequest{Teacher: "Tom@Gmail.com", Students: []string{"jerry@gmail.com"}})
+				return err
+			},
+			func(mock pgxmock.PgxConnIface) {
+				addCheckTeacherExistsQuery(mock, "tom@gmail.com", true)
+				addCheckStudentExistsQueries(mock, []string{"jerry@gmail.com"}, []bool{true})
+				addCheckTeacherStudentRelationshipExistsQueries(mock, "tom@gmail.com", []string{"jerry@gmail.com"}, []bool{false})
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta("INSERT INTO teacher_student_relationship(teacher, student) VALUES ($1, $2)")).WithArgs("tom@gmail.com", "jerry@gmail.com").WillReturnRows(pgxmock.NewRows([]string{"id", "teacher", "student"}))
+				mock.ExpectCommit()
+				addRecordAuditEventQuery(mock, "register", []string{"tom@gmail.com", "jerry@gmail.com"}, 200, nil)
+			},
+			codes.OK, "",
+		},
+		{
+			"Invalid emails are invalid arguments",
+			testConfig(), nil,
+			func(ctx context.Context, client onecvpb.OneCVClient) error {
+				_, err := client.GetCommonStudents(ctx, &onecvpb.GetCommonStudentsRequest{Teachers: []string{"tomgmail.com"}})
+				return err
+			},
+			nil,
+			codes.InvalidArgument, fmt.Errorf(customErrors["invalidEmail"].Message, errors.New("invalidEmail"), "'tomgmail.com'").Error(),
+		},
+		{
+			"Unknown teachers are invalid arguments, as over HTTP",
+			testConfig(), nil,
+			func(ctx context.Context, client onecvpb.OneCVClient) error {
+				_, err := client.RetrieveForNotifications(ctx, &onecvpb.RetrieveForNotificationsRequest{Teacher: "tom@gmail.com", Notification: "Hello"})
+				return err
+			},
+			func(mock pgxmock.PgxConnIface) {
+				addCheckTeacherExistsQuery(mock, "tom@gmail.com", false)
+				addRecordAuditEventQuery(mock, "notify", []string{"tom@gmail.com"}, 400, nil)
+			},
+			codes.InvalidArgument, fmt.Errorf(models.CustomErrors["nonExistentTeacher"].Message, errors.New("nonExistentTeacher"), "tom@gmail.com").Error(),
+		},
+		{
+			"Database errors are hidden",
+			testConfig(), nil,
+			func(ctx context.Context, client onecvpb.OneCVClient) error {
+				_, err := client.SuspendStudent(ctx, &onecvpb.SuspendStudentRequest{Student: "jerry@gmail.com"})
+				return err
+			},
+			func(mock pgxmock.PgxConnIface) {
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT email FROM student WHERE email = $1")).WithArgs("jerry@gmail.com").WillReturnError(errors.New("connection reset"))
+				addRecordAuditEventQuery(mock, "suspend", []string{"jerry@gmail.com"}, 500, nil)
+			},
+			codes.Internal, internalErrorMessage,
+		},
+		{
+			"Missing credentials",
+			testAuthConfig(), nil,
+			func(ctx context.Context, client onecvpb.OneCVClient) error {
+				_, err := client.GetCommonStudents(ctx, &onecvpb.GetCommonStudentsRequest{Teachers: []string{"tom@gmail.com"}})
+				return err
+			},
+			nil,
+			codes.Unauthenticated, fmt.Errorf(customErrors["unauthenticated"].Message, errors.New("unauthenticated"), errors.New("A bearer token or API key is required")).Error(),
+		},
+		{
+			"Read-only API keys cannot suspend students",
+			testAuthConfig(), map[string]string{"x-api-key": "reporting-key"},
+			func(ctx context.Context, client onecvpb.OneCVClient) error {
+				_, err := client.SuspendStudent(ctx, &onecvpb.SuspendStudentRequest{Student: "jerry@gmail.com"})
+				return err
+			},
+			func(mock pgxmock.PgxConnIface) {
+				addRecordAuditEventQueryAs(mock, "reporting", "suspend", []string{}, []string{}, 403)
+			},
+			codes.PermissionDenied, fmt.Errorf(customErrors["adminRoleRequired"].Message, errors.New("adminRoleRequired"), "suspend students").Error(),
+		},
+		{
+			"Teachers can only notify as themselves",
+			testAuthConfig(), map[string]string{"authorization": "Bearer " + signTestToken(t, tokenClaims{Role: teacherRole, RegisteredClaims: jwt.RegisteredClaims{Subject: "quacker@gmail.com", Issuer: "onecv-test", ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour))}}, testJWTSecret)},
+			func(ctx context.Context, client onecvpb.OneCVClient) error {
+				_, err := client.RetrieveForNotifications(ctx, &onecvpb.RetrieveForNotificationsRequest{Teacher: "tom@gmail.com", Notification: "Hello"})
+				return err
+			},
+			func(mock pgxmock.PgxConnIface) {
+				addRecordAuditEventQueryAs(mock, "quacker@gmail.com", "notify", []string{"tom@gmail.com"}, []string{}, 403)
+			},
+			codes.PermissionDenied, fmt.Errorf(customErrors["notYourself"].Message, errors.New("notYourself"), "send notifications", "quacker@gmail.com").Error(),
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.testCaseDesc, func(t *testing.T) {
+			mock, err := pgxmock.NewConn()
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer mock.Close(context.Background())
+
+			if testCase.addExpectedQueries != nil {
+				testCase.addExpectedQueries(mock)
+			}
+			models.DB = mock
+
+			ctx := metadata.NewOutgoingContext(context.Background(), metadata.New(testCase.metadata))
+			err = testCase.call(ctx, newTestGRPCClient(t, testCase.cfg))
+
+			checkQueryExpectations(mock, t)
+			gotStatus := status.Convert(err)
+			if gotStatus.Code() != testCase.wantCode || gotStatus.Message() != testCase.wantMessage {
+				t.Errorf("wrong status:\nwant: %s %q\n got: %s %q", testCase.wantCode, testCase.wantMessage, gotStatus.Code(), gotStatus.Message())
+			}
+		})
+	}
+}
+
+func TestGRPCAuditsSuspensions(t *testing.T) {
+	mock, err := pgxmock.NewConn()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mock.Close(context.Background())
+
+	request := &onecvpb.SuspendStudentRequest{Student: "Jerry@Gmail.com"}
+	payload, err := proto.MarshalOptions{Deterministic: true}.Marshal(request)
+	if err != nil {
+		t.Fatalf("encoding request: %v", err)
+	}
+	payloadHash := sha256.Sum256(payload)
+
+	addCheckStudentExistsQuery(mock, "jerry@gmail.com", true)
+	mock.ExpectQuery(regexp.QuoteMeta("UPDATE student SET suspended = true WHERE email = $1")).WithArgs("jerry@gmail.com").WillReturnRows(pgxmock.NewRows([]string{}))
+	mock.ExpectQuery(regexp.QuoteMeta(`
+		INSERT INTO audit_event(actor, action, target_emails, target_ids, payload_hash, outcome, status, request_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id
+	`)).WithArgs("principal@gmail.com", "suspend", []string{"jerry@gmail.com"}, []string{}, hex.EncodeToString(payloadHash[:]), "ok", 200, "grpc-request-1").
+		WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(int64(1)))
+	models.DB = mock
+
+	token := signTestToken(t, tokenClaims{Role: adminRole, RegisteredClaims: jwt.RegisteredClaims{Subject: "principal@gmail.com", Issuer: "onecv-test", ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour))}}, testJWTSecret)
+	ctx := metadata.NewOutgoingContext(context.Background(), metadata.Pairs("authorization", "Bearer "+token, "x-request-id", "grpc-request-1"))
+
+	var header metadata.MD
+	if _, err := newTestGRPCClient(t, testAuthConfig()).SuspendStudent(ctx, request, grpc.Header(&header)); err != nil {
+		t.Fatalf("suspending student: %v", err)
+	}
+
+	checkQueryExpectations(mock, t)
+	if requestID := header.Get("x-request-id"); len(requestID) != 1 || requestID[0] != "grpc-request-1" {
+		t.Errorf("want the request id echoed in the x-request-id header, got %v", requestID)
+	}
+}
+
+func TestGRPCRateLimit(t *testing.T) {
+	cfg := testConfig()
+	cfg.RateLimit.Routes = map[string]config.RateLimit{"GET /api/commonstudents": {Rate: 0.1, Burst: 1}}
+	client := newTestGRPCClient(t, cfg)
+
+	// Invalid teachers are rejected before any query, so no stub database is needed
+	request := &onecvpb.GetCommonStudentsRequest{Teachers: []string{"invalid"}}
+	if _, err := client.GetCommonStudents(context.Background(), request); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("first call should reach the method, got %v", err)
+	}
+
+	var header metadata.MD
+	_, err := client.GetCommonStudents(context.Background(), request, grpc.Header(&header))
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("second call should be limited by the route's HTTP limit, got %v", err)
+	}
+	if retryAfter := header.Get("retry-after"); len(retryAfter) != 1 || retryAfter[0] != "10" {
+		t.Errorf("want a retry-after header of 10, got %v", retryAfter)
+	}
+}
+
+func TestGRPCFailedAuthenticationIsRateLimited(t *testing.T) {
+	cfg := testAuthConfig()
+	cfg.RateLimit.PerIP = config.RateLimit{Rate: 0.1, Burst: 2}
+	client := newTestGRPCClient(t, cfg)
+
+	ctx := metadata.NewOutgoingContext(context.Background(), metadata.Pairs("x-api-key", "guess"))
+	for index, wantCode := range []codes.Code{codes.Unauthenticated, codes.Unauthenticated, codes.ResourceExhausted} {
+		_, err := client.GetCommonStudents(ctx, &onecvpb.GetCommonStudentsRequest{Teachers: []string{"tom@gmail.com"}})
+		if status.Code(err) != wantCode {
+			t.Errorf("attempt %d:\nwant: %s\n got: %s", index+1, wantCode, status.Code(err))
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"onecv-go-backend/migrations"
+	"onecv-go-backend/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Readiness checks must answer well within the orchestrator's probe timeout
+const readinessCheckTimeout = 2 * time.Second
+
+type componentStatus struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+type healthResponseBody struct {
+	Status     string                     `json:"status"`
+	Components map[string]componentStatus `json:"components,omitempty"`
+}
+
+// getHealth reports that the process is up and serving requests. It deliberately ignores
+// dependencies, so an unreachable database does not get the process restarted
+func getHealth(c *gin.Context) {
+	c.IndentedJSON(http.StatusOK, healthResponseBody{Status: "ok"})
+}
+
+// getReadiness reports whether requests can be served: the database must be reachable and fully migrated
+func getReadiness(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), readinessCheckTimeout)
+	defer cancel()
+
+	logger := requestLogger(c)
+	components := map[string]componentStatus{
+		"database": checkDatabase(ctx, logger),
+		"migrations": checkMigrations(ctx, logger),
+	}
+
+	httpStatus, status := http.StatusOK, "ok"
+	for _, component := range components {
+		if component.Status != "ok" {
+			httpStatus, status = http.StatusServiceUnavailable, "unavailable"
+		}
+	}
+
+	c.IndentedJSON(httpStatus, healthResponseBody{Status: status, Components: components})
+}
+
+// The checks log their errors rather than return them, as /readyz is public and database errors can reveal
+// hostnames, ports and user names
+func checkDatabase(ctx context.Context, logger *slog.Logger) componentStatus {
+	if err := models.DB.Ping(ctx); err != nil {
+		logger.Error("Readiness check failed", "component", "database", "error", err)
+		return componentStatus{Status: "unavailable"}
+	}
+	return componentStatus{Status: "ok"}
+}
+
+func checkMigrations(ctx context.Context, logger *slog.Logger) componentStatus {
+	unapplied, err := migrations.Unapplied(ctx, models.DB)
+	if err != nil {
+		logger.Error("Readiness check failed", "component", "migrations", "error", err)
+		return componentStatus{Status: "unavailable"}
+	}
+
+	if len(unapplied) > 0 {
+		pending := []string{}
+		for _, migration := range unapplied {
+			pending = append(pending, fmt.Sprintf("%04d_%s", migration.Version, migration.Name))
+		}
+		return componentStatus{Status: "pending", Error: "Unapplied migrations: " + strings.Join(pending, ", ")}
+	}
+
+	return componentStatus{Status: "ok"}
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"onecv-go-backend/migrations"
+	"onecv-go-backend/models"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/pashagolub/pgxmock/v3"
+)
+
+func TestHealth(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest("GET", "/healthz", nil)
+	if err != nil {
+		t.Fatalf("building request: %v", err)
+	}
+
+	testRouter.ServeHTTP(recorder, request)
+
+	checkStatusAndResponse[healthResponseBody](recorder, t, testCaseStruct{200, healthResponseBody{Status: "ok"}})
+}
+
+type readinessTestCase struct {
+	testCaseDesc string
+	pingError error
+	appliedMigrations int
+	wantCode int
+	wantComponentStatuses map[string]string
+}
+
+func TestReadiness(t *testing.T) {
+	allMigrations, err := migrations.Load()
+	if err != nil {
+		t.Fatalf("loading migrations: %v", err)
+	}
+
+	testCases := []readinessTestCase{
+		{
+			"Database reachable and migrated",
+			nil,
+			len(allMigrations),
+			200,
+			map[string]string{"database": "ok", "migrations": "ok"},
+		},
+		{
+			"Database unreachable",
+			errors.New("connection refused"),
+			len(allMigrations),
+			503,
+			map[string]string{"database": "unavailable", "migrations": "ok"},
+		},
+		{
+			"Pending migrations",
+			nil,
+			len(allMigrations) - 1,
+			503,
+			map[string]string{"database": "ok", "migrations": "pending"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testCaseDesc, func(t *testing.T) {
+			mock, err := pgxmock.NewConn()
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer mock.Close(context.Background())
+
+			mock.ExpectPing().WillReturnError(tc.pingError)
+
+			appliedRows := pgxmock.NewRows([]string{"version", "applied_at"})
+			for _, migration := range allMigrations[:tc.appliedMigrations] {
+				appliedRows.AddRow(migration.Version, time.Now())
+			}
+			mock.ExpectQuery(regexp.QuoteMeta("SELECT version, applied_at FROM schema_migrations")).WillReturnRows(appliedRows)
+
+			models.DB = mock
+
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest("GET", "/readyz", nil)
+			if err != nil {
+				t.Fatalf("building request: %v", err)
+			}
+
+			testRouter.ServeHTTP(recorder, request)
+
+			checkQueryExpectations(mock, t)
+			if recorder.Code != tc.wantCode {
+				t.Errorf("wrong response code:\nwant: %v\n got: %v", tc.wantCode, recorder.Code)
+			}
+
+			responseBody := getResponseBody[healthResponseBody](recorder, t)
+			for component, wantStatus := range tc.wantComponentStatuses {
+				if got := responseBody.Components[component].Status; got != wantStatus {
+					t.Errorf("wrong %s status:\nwant: %v\n got: %v", component, wantStatus, got)
+				}
+			}
+			if tc.pingError != nil && responseBody.Components["database"].Error != "" {
+				t.Errorf("database errors must not be returned, got %q", responseBody.Components["database"].Error)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"os"
+	"regexp"
+	"time"
+
+	"onecv-go-backend/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+const requestIDHeader = "X-Request-ID"
+const requestIDContextKey = "requestID"
+
+// Request ids from clients and proxies are echoed into logs and responses, so only accept sane ones
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+func newLogger(cfg config.LogConfig) *slog.Logger {
+	var level slog.Level
+	level.UnmarshalText([]byte(cfg.Level)) // Already validated by config.Validate
+
+	options := &slog.HandlerOptions{Level: level}
+	if cfg.Format == "text" {
+		return slog.New(slog.NewTextHandler(os.Stderr, options))
+	}
+	return slog.New(slog.NewJSONHandler(os.Stderr, options))
+}
+
+// assignRequestID reuses the caller's X-Request-ID (e.g. from a load balancer) or generates one,
+// and echoes it back so clients can quote it when reporting problems
+func assignRequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(requestIDHeader)
+		if !validRequestID.MatchString(requestID) {
+			requestID = newRequestID()
+		}
+
+		c.Set(requestIDContextKey, requestID)
+		c.Header(requestIDHeader, requestID)
+		c.Next()
+	}
+}
+
+func newRequestID() string {
+	bytes := make([]byte, 16)
+	rand.Read(bytes)
+	return hex.EncodeToString(bytes)
+}
+
+func getRequestID(c *gin.Context) string {
+	return c.GetString(requestIDContextKey)
+}
+
+func requestLogger(c *gin.Context) *slog.Logger {
+	return slog.Default().With("requestId", getRequestID(c))
+}
+
+func logRequests() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		if status >= 500 {
+			level = slog.LevelError
+		} else if status >= 400 {
+			level = slog.LevelWarn
+		}
+
+		logger := requestLogger(c)
+		if authenticated, ok := getPrincipal(c); ok {
+			logger = logger.With("principal", authenticated.Subject)
+		}
+
+		logger.Log(c.Request.Context(), level, "Handled request",
+			"method", c.Request.Method,
+			"path", c.Request.URL.Path,
+			"route", c.FullPath(),
+			"status", status,
+			"durationMs", float64(time.Since(start).Microseconds())/1000,
+			"bytes", c.Writer.Size(),
+			"clientIp", c.ClientIP(),
+		)
+	}
+}
+
+// recoverFromPanics logs panics with their request id and answers with the usual error body
+func recoverFromPanics() gin.HandlerFunc {
+	return gin.CustomRecoveryWithWriter(nil, func(c *gin.Context, recovered any) {
+		requestLogger(c).Error("Recovered from panic", "panic", recovered)
+		c.AbortWithStatusJSON(http.StatusInternalServerError, errorResponseBody{Message: internalErrorMessage, RequestID: getRequestID(c)})
+	})
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"onecv-go-backend/models"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/pashagolub/pgxmock/v3"
+)
+
+func TestRequestID(t *testing.T) {
+	testCases := []struct {
+		testCaseDesc string
+		requestID string
+		wantRequestID *regexp.Regexp
+	}{
+		{"Request id from the caller is propagated", "lb-4f1c2a", regexp.MustCompile(`^lb-4f1c2a$`)},
+		{"Missing request id is generated", "", regexp.MustCompile(`^[0-9a-f]{32}$`)},
+		{"Unsafe request id is replaced", "<script>", regexp.MustCompile(`^[0-9a-f]{32}$`)},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testCaseDesc, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest("GET", "/healthz", nil)
+			if err != nil {
+				t.Fatalf("building request: %v", err)
+			}
+			request.Header.Set(requestIDHeader, tc.requestID)
+
+			testRouter.ServeHTTP(recorder, request)
+
+			if got := recorder.Header().Get(requestIDHeader); !tc.wantRequestID.MatchString(got) {
+				t.Errorf("wrong request id:\nwant: %v\n got: %v", tc.wantRequestID, got)
+			}
+		})
+	}
+}
+
+func TestInternalErrorsAreLogged(t *testing.T) {
+	var logs bytes.Buffer
+	defaultLogger := slog.Default()
+	slog.SetDefault(slog.New(slog.NewJSONHandler(&logs, nil)))
+	defer slog.SetDefault(defaultLogger)
+
+	mock, err := pgxmock.NewConn()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mock.Close(context.Background())
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT email FROM teacher WHERE email = $1")).WithArgs("tom@gmail.com").WillReturnError(errors.New("conn closed"))
+	models.DB = mock
+
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest("GET", "/api/commonstudents?teacher=tom@gmail.com", nil)
+	if err != nil {
+		t.Fatalf("building request: %v", err)
+	}
+	request.Header.Set(requestIDHeader, "req-500")
+
+	testRouter.ServeHTTP(recorder, request)
+
+	checkQueryExpectations(mock, t)
+	checkStatusAndResponse[commonStudentsSuccessBody](recorder, t, testCaseStruct{500, errorResponseBody{Message: internalErrorMessage}})
+
+	for _, want := range []string{`"msg":"Request failed"`, `"requestId":"req-500"`, `"error":"conn closed"`} {
+		if !strings.Contains(logs.String(), want) {
+			t.Errorf("logs are missing %s:\n%s", want, logs.String())
+		}
+	}
+}
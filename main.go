@@ -3,43 +3,85 @@ package main
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"net/http"
+	"onecv-go-backend/config"
 	"onecv-go-backend/models"
 	"os"
 	"strings"
-	"log"
 	"errors"
 
 	"github.com/gin-gonic/gin"
-	"github.com/jackc/pgx/v5"
-	"github.com/joho/godotenv"
+	"go.opentelemetry.io/otel/trace"
 )
 
 func main() {
-	err := godotenv.Load(".env")
-	if err != nil && os.Getenv("GIN_MODE") == "" {
-		log.Fatalf("Some error occured. Err: %s", err)
+	if isHelpCommand(os.Args[1:]) {
+		fmt.Fprintln(os.Stdout, usage)
+		return
+	}
+
+	cfg, args, err := config.Load(os.Args[1:])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
 	}
-	
-	var dbConnectionError error
-	models.DB, dbConnectionError = pgx.Connect(context.Background(), os.Getenv("DATABASE_URL"))
+	slog.SetDefault(newLogger(cfg.Log))
+
+	tracerProvider, shutdownTracing, err := newTracerProvider(cfg.Tracing)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	defer shutdownTracing(context.Background())
+
+	pool, dbConnectionError := connectDatabase(cfg.Database, tracerProvider)
 	if dbConnectionError != nil {
 		fmt.Fprintf(os.Stderr, "Unable to connect to database: %v\n", dbConnectionError)
 		os.Exit(1)
 	}
-	defer models.DB.Close(context.Background())
+	models.DB = pool
+	defer pool.Close()
 
-	router := router()
-	router.Run(":8080")
+	if err := runCommand(cfg, pool, tracerProvider, args); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		pool.Close()
+		shutdownTracing(context.Background())
+		os.Exit(1)
+	}
 }
 
 // Need a router factory so that the same router can be assessed by test scripts
-func router() *gin.Engine {
-	router := gin.Default()
-	router.POST("/api/register", registerStudents)
-	router.GET("/api/commonstudents", getCommonStudents)
-	router.POST("/api/suspend", suspendStudent)
-	router.POST("/api/retrievefornotifications", retrieveForNotifications)
+func router(cfg config.Config, tracerProvider trace.TracerProvider, rateLimitStore rateLimitStore) *gin.Engine {
+	router := gin.New()
+	router.Use(assignRequestID(), traceRequests(tracerProvider), logRequests(), recoverFromPanics(), recordMetrics(), cors(cfg.CORS))
+
+	router.GET("/healthz", getHealth)
+	router.GET("/readyz", getReadiness)
+	router.GET("/metrics", getMetrics())
+	router.GET("/api/openapi.json", getOpenAPIDocument(router))
+
+	api := router.Group("/api", rateLimitIP(cfg.RateLimit, rateLimitStore), authenticate(cfg.Auth), rateLimit(cfg.RateLimit, rateLimitStore))
+	api.POST("/register", audit("register"), registerStudents)
+	api.POST("/register/batch", audit("register_batch"), registerStudentsBatch)
+	api.GET("/commonstudents", getCommonStudents)
+	api.GET("/students", requireAdmin("search students"), searchStudents)
+	api.GET("/students/:student", getStudent)
+	api.GET("/students/:student/teachers", getStudentTeachers)
+	api.GET("/commonteachers", getCommonTeachers)
+	api.POST("/suspend", audit("suspend"), requireAdmin("suspend students"), suspendStudent)
+	api.POST("/retrievefornotifications", audit("notify"), retrieveForNotifications)
+	api.PATCH("/students/:student", audit("update_student"), requireAdmin("update students"), updateStudent)
+	api.GET("/notifications/scheduled", getScheduledNotifications)
+	api.DELETE("/notifications/scheduled/:id", audit("cancel_notification"), cancelScheduledNotification)
+	api.GET("/audit", requireAdmin("read the audit log"), getAuditEvents)
+	api.GET("/reports/teachers", requireAdmin("read reports"), getTeacherReport)
+	api.GET("/reports/suspensions", requireAdmin("read reports"), getSuspensionReport)
+	api.GET("/reports/notifications", requireAdmin("read reports"), getNotificationReport)
+
+	addV2Routes(api)
+
+	router.POST("/graphql", rateLimitIP(cfg.RateLimit, rateLimitStore), authenticate(cfg.Auth), rateLimit(cfg.RateLimit, rateLimitStore), postGraphQL)
 	return router
 }
 
@@ -49,30 +91,28 @@ func registerStudents(c *gin.Context) {
 	var studentRegistrationData models.StudentRegistrationData[string]
 	if err := c.BindJSON(&studentRegistrationData); err != nil {
 		err := fmt.Errorf(customErrors["invalidDataType"].Message, errors.New("invalidDataType"))
-		httpStatus, message := getStatusAndMessage(err)
-		c.IndentedJSON(httpStatus, errorResponseBody{message})			
+		respondWithError(c, err)
 		return
 	}
 
-	//Parameter validation (remove duplicates, check for @gmail.com))
-	studentRegistrationData.Students = removeDuplicateStr(studentRegistrationData.Students)
-
-	allEmails := append(studentRegistrationData.Students, studentRegistrationData.Teacher)
-	invalidEmails := getInvalidEmails(allEmails)
+	//Parameter validation (normalize, remove duplicates, check for @gmail.com))
+	studentRegistrationData, err := prepareStudentRegistration(studentRegistrationData)
+	setAuditTargets(c, append([]string{studentRegistrationData.Teacher}, studentRegistrationData.Students...)...)
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
 
-	if haveInvalidEmails := len(invalidEmails) > 0; haveInvalidEmails {
-		err := fmt.Errorf(customErrors["invalidEmail"].Message, errors.New("invalidEmail"), strings.Join(invalidEmails, ", "))
-		httpStatus, message := getStatusAndMessage(err)
-		c.IndentedJSON(httpStatus, errorResponseBody{message})				
+	if err := authorizeTeacher(c, studentRegistrationData.Teacher, "register students"); err != nil {
+		respondWithError(c, err)
 		return
 	}
 
 	//Register the student
-	err := models.RegisterStudents(studentRegistrationData)
+	err = models.RegisterStudents(c.Request.Context(), studentRegistrationData)
 
 	if err != nil {
-		httpStatus, message := getStatusAndMessage(err)
-		c.IndentedJSON(httpStatus, errorResponseBody{Message: message})		
+		respondWithError(c, err)
 		return
 	}
 
@@ -88,22 +128,20 @@ func getCommonStudents(c *gin.Context) {
 	queryParams := c.Request.URL.Query()
 	teachers := queryParams["teacher"]
 
-	//Parameter validation (remove duplicates, check for @gmail.com)
-	teachers = removeDuplicateStr(teachers)
+	//Parameter validation (normalize, remove duplicates, check for @gmail.com)
+	teachers = removeDuplicateStr(normalizeEmails(teachers))
 
 	invalidEmails := getInvalidEmails(teachers)
 	if haveInvalidEmails := len(invalidEmails) > 0; haveInvalidEmails {
 		err := fmt.Errorf(customErrors["invalidEmail"].Message, errors.New("invalidEmail"), strings.Join(invalidEmails, ", "))
-		httpStatus, message := getStatusAndMessage(err)
-		c.IndentedJSON(httpStatus, errorResponseBody{Message: message})				
+		respondWithError(c, err)
 		return
 	}
 
 	//Get common students
-	commonStudents, err := models.GetCommonStudents(teachers)
+	commonStudents, err := models.GetCommonStudents(c.Request.Context(), teachers)
 	if err != nil {
-		httpStatus, message := getStatusAndMessage(err)
-		c.IndentedJSON(httpStatus, errorResponseBody{Message: message})
+		respondWithError(c, err)
 		return
 	}
 
@@ -111,40 +149,137 @@ func getCommonStudents(c *gin.Context) {
 
 }
 
+type studentTeachersSuccessBody struct {
+	Teachers []string `json:"teachers"`
+}
+
+func getStudent(c *gin.Context) {
+	//Parameter validation (normalize, check for @gmail.com)
+	email, ok := emailParam(c, "student")
+	if !ok { return }
+
+	//Get the student, with the id that PATCH /api/students/:student needs
+	student, err := models.GetStudent(c.Request.Context(), email)
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, student)
+}
+
+func getStudentTeachers(c *gin.Context) {
+	//Parameter validation (normalize, check for @gmail.com)
+	student, ok := emailParam(c, "student")
+	if !ok { return }
+
+	//Get the student's teachers
+	teachers, err := models.GetStudentTeachers(c.Request.Context(), student)
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, studentTeachersSuccessBody{teachers})
+}
+
+func getCommonTeachers(c *gin.Context) {
+	queryParams := c.Request.URL.Query()
+	students := queryParams["student"]
+
+	//Parameter validation (normalize, remove duplicates, check for @gmail.com)
+	students = removeDuplicateStr(normalizeEmails(students))
+	if err := checkEmails(students); err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	//Get common teachers
+	commonTeachers, err := models.GetCommonTeachers(c.Request.Context(), students)
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, studentTeachersSuccessBody{commonTeachers})
+}
+
 type suspendStudentSuccessBody struct {}
 
 func suspendStudent(c *gin.Context) {
 	var studentSuspensionData models.StudentSuspensionData[string]
 	if err := c.BindJSON(&studentSuspensionData); err != nil {
 		err := fmt.Errorf(customErrors["invalidDataType"].Message, errors.New("invalidDataType"))
-		httpStatus, message := getStatusAndMessage(err)
-		c.IndentedJSON(httpStatus, errorResponseBody{message})			
+		respondWithError(c, err)
 		return
 	}
 
-	//Parameter validation (check for @gmail.com)
+	//Parameter validation (normalize, check for @gmail.com)
+	studentSuspensionData.Student = normalizeEmail(studentSuspensionData.Student)
+	setAuditTargets(c, studentSuspensionData.Student)
 	invalidEmails := getInvalidEmails([]string{studentSuspensionData.Student})
 
 	if haveInvalidEmails := len(invalidEmails) > 0; haveInvalidEmails {
 		err := fmt.Errorf(customErrors["invalidEmail"].Message, errors.New("invalidEmail"), strings.Join(invalidEmails, ", "))
-		httpStatus, message := getStatusAndMessage(err)
-		c.IndentedJSON(httpStatus, errorResponseBody{message})				
+		respondWithError(c, err)
 		return
 	}
 
 	//Register the student
-	err := models.SuspendStudent(studentSuspensionData)
+	err := models.SuspendStudent(c.Request.Context(), studentSuspensionData)
 	if err != nil {
-		httpStatus, message := getStatusAndMessage(err)
-		c.IndentedJSON(httpStatus, errorResponseBody{Message: message})		
+		respondWithError(c, err)
 		return
 	}
 
 	c.Status(http.StatusNoContent)
 }
 
+func updateStudent(c *gin.Context) {
+	id := c.Param("student")
+
+	var studentUpdateData models.StudentUpdateData
+	if err := c.BindJSON(&studentUpdateData); err != nil {
+		err := fmt.Errorf(customErrors["invalidDataType"].Message, errors.New("invalidDataType"))
+		respondWithError(c, err)
+		return
+	}
+
+	//Parameter validation (check the id is a UUID, normalize, check for @gmail.com)
+	if !validateID(id) {
+		err := fmt.Errorf(customErrors["invalidID"].Message, errors.New("invalidID"), id)
+		respondWithError(c, err)
+		return
+	}
+
+	setAuditTargetIDs(c, id)
+
+	studentUpdateData.Email = normalizeEmail(studentUpdateData.Email)
+	setAuditTargets(c, studentUpdateData.Email)
+	invalidEmails := getInvalidEmails([]string{studentUpdateData.Email})
+
+	if haveInvalidEmails := len(invalidEmails) > 0; haveInvalidEmails {
+		err := fmt.Errorf(customErrors["invalidEmail"].Message, errors.New("invalidEmail"), strings.Join(invalidEmails, ", "))
+		respondWithError(c, err)
+		return
+	}
+
+	//Change the student's email
+	student, previousEmail, err := models.UpdateStudentEmail(c.Request.Context(), id, studentUpdateData)
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+	// So that the event is found by searching for either email
+	setAuditTargets(c, previousEmail, student.Email)
+
+	c.IndentedJSON(http.StatusOK, student)
+}
+
 type retrieveForNotificationsSuccessBody struct {
 	Recipients []string `json:"recipients"`
+	// Only for dry runs: every student the notification concerns, including those excluded for suspension
+	Students []models.NotificationRecipient `json:"students,omitempty"`
 }
 
 func retrieveForNotifications(c *gin.Context) {
@@ -152,49 +287,62 @@ func retrieveForNotifications(c *gin.Context) {
 
 	if err := c.BindJSON(&retrieveForNotificationsData); err != nil {
 		err := fmt.Errorf(customErrors["invalidDataType"].Message, errors.New("invalidDataType"))
-		httpStatus, message := getStatusAndMessage(err)
-		c.IndentedJSON(httpStatus, errorResponseBody{message})			
+		respondWithError(c, err)
 		return
 	}
+	if retrieveForNotificationsData.DryRun {
+		setAuditAction(c, notifyPreviewAuditAction)
+	}
 
-	teacher := retrieveForNotificationsData.Teacher
-	notification := retrieveForNotificationsData.Notification
-	notificationWords := strings.Split(notification, " ")
-
-	students := []string{}
-	for _, word := range notificationWords {
-		if strings.HasPrefix(word, "@") {
-			students = append(students, word[1:])
-		}
+	//Parameter validation (check for @gmail.com)
+	retrieveForNotificationsProcessedData, err := prepareNotification(retrieveForNotificationsData)
+	teacher := retrieveForNotificationsProcessedData.Teacher
+	setAuditTargets(c, append([]string{teacher}, retrieveForNotificationsProcessedData.Students...)...)
+	if err != nil {
+		respondWithError(c, err)
+		return
 	}
 
-	//Parameter validation (remove duplicates, check for @gmail.com))
-	students = removeDuplicateStr(students)
+	if err := authorizeTeacher(c, teacher, "send notifications"); err != nil {
+		respondWithError(c, err)
+		return
+	}
 
-	allEmails := append(students, teacher)
-	invalidEmails := getInvalidEmails(allEmails)
+	if retrieveForNotificationsData.DryRun {
+		previewNotification(c, retrieveForNotificationsProcessedData)
+		return
+	}
 
-	if haveInvalidEmails := len(invalidEmails) > 0; haveInvalidEmails {
-		err := fmt.Errorf(customErrors["invalidEmail"].Message, errors.New("invalidEmail"), strings.Join(invalidEmails, ", "))
-		httpStatus, message := getStatusAndMessage(err)
-		c.IndentedJSON(httpStatus, errorResponseBody{message})				
+	if retrieveForNotificationsData.SendAt != nil {
+		scheduleNotification(c, retrieveForNotificationsProcessedData, *retrieveForNotificationsData.SendAt)
 		return
 	}
 
-	//Register the student
-	retrieveForNotificationsProcessedData := models.RetrieveForNotificationsProcessedData[string] {
-		Teacher: teacher,
-		Students: students,
+	//Retrieve the recipients
+	recipients, err := models.RetrieveForNotifications(c.Request.Context(), retrieveForNotificationsProcessedData)
+
+	if err != nil {
+		respondWithError(c, err)
+		return
 	}
 
-	recipients, err := models.RetrieveForNotifications(retrieveForNotificationsProcessedData)
+	c.IndentedJSON(http.StatusOK, retrieveForNotificationsSuccessBody{Recipients: recipients})
 
+}
+// previewNotification answers with who a notification would reach and why, without sending it
+func previewNotification(c *gin.Context, retrieveForNotificationsProcessedData models.RetrieveForNotificationsProcessedData[string]) {
+	students, err := models.ExplainNotificationRecipients(c.Request.Context(), retrieveForNotificationsProcessedData)
 	if err != nil {
-		httpStatus, message := getStatusAndMessage(err)
-		c.IndentedJSON(httpStatus, errorResponseBody{Message: message})		
+		respondWithError(c, err)
 		return
 	}
 
-	c.IndentedJSON(http.StatusOK, retrieveForNotificationsSuccessBody{recipients})
+	recipients := []string{}
+	for _, student := range students {
+		if !student.Suspended {
+			recipients = append(recipients, student.Email)
+		}
+	}
 
-}
\ No newline at end of file
+	c.IndentedJSON(http.StatusOK, retrieveForNotificationsSuccessBody{recipients, students})
+}
@@ -5,10 +5,18 @@ import (
 	"onecv-go-backend/models"
 	"net/mail"
 	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 type errorResponseBody struct {
 	Message string `json:"message"`
+	// The error code of 403 responses, so that clients can tell why they were refused
+	Reason string `json:"reason,omitempty"`
+	RequestID string `json:"requestId,omitempty"`
 }
 
 type customError struct {
@@ -19,6 +27,17 @@ type customError struct {
 var customErrors = map[string]customError{
 	"invalidEmail" : {"%w: You have provided one or more invalid emails: %s ", 400},
 	"invalidDataType" : {"%w: The JSON sent does not have the correct structure and/or types", 400},
+	"invalidID" : {"%w: '%s' is not a valid id", 400},
+	"invalidQueryParameter" : {"%w: The query parameter '%s' must be %s", 400},
+	"unauthenticated" : {"%w: %s", 401},
+	"adminRoleRequired" : {"%w: Only administrators can %s", 403},
+	"teacherRoleRequired" : {"%w: Only teachers and administrators can %s", 403},
+	"notYourself" : {"%w: Teachers can only %s as themselves, but you are signed in as %s", 403},
+	"rateLimited" : {"%w: Too many requests. Please try again in %d second(s)", 429},
+	"invalidBatchSize" : {"%w: A batch can have between 1 and %d entries, but %d were sent", 400},
+	"bodyTooLarge" : {"%w: The request body must not be larger than %d bytes", 413},
+	"sendAtInPast" : {"%w: sendAt must be in the future, but it is %s", 400},
+	"queryTooDeep" : {"%w: GraphQL queries can be at most %d fields deep, but this one is %d", 400},
 }
 
 func removeDuplicateStr(strSlice []string) []string {
@@ -38,6 +57,43 @@ func validateEmail (email string) bool {
     return err == nil
 }
 
+// Emails are identities, so 'Tom@Gmail.com ' and 'tom@gmail.com' must refer to the same person.
+// Invalid emails are returned untouched so that error messages echo exactly what was sent
+func normalizeEmail(email string) string {
+	trimmed := strings.TrimSpace(email)
+	if !validateEmail(trimmed) {
+		return email
+	}
+	return strings.ToLower(trimmed)
+}
+
+func normalizeEmails(emails []string) []string {
+	normalizedEmails := make([]string, len(emails))
+	for index, email := range emails {
+		normalizedEmails[index] = normalizeEmail(email)
+	}
+	return normalizedEmails
+}
+
+// getMentionedStudents returns the normalized, deduplicated emails @mentioned in a notification
+func getMentionedStudents(notification string) []string {
+	notificationWords := strings.Split(notification, " ")
+
+	students := []string{}
+	for _, word := range notificationWords {
+		if strings.HasPrefix(word, "@") {
+			students = append(students, word[1:])
+		}
+	}
+
+	return removeDuplicateStr(normalizeEmails(students))
+}
+
+func validateID(id string) bool {
+	var uuid pgtype.UUID
+	return uuid.Scan(id) == nil
+}
+
 func getInvalidEmails (allEmails []string) []string {
 
 	invalidEmails := []string{}
@@ -49,6 +105,74 @@ func getInvalidEmails (allEmails []string) []string {
 	return invalidEmails
 }
 
+// checkEmails returns an invalidEmail error naming every invalid email, or nil if they are all valid
+func checkEmails(emails []string) error {
+	invalidEmails := getInvalidEmails(emails)
+	if haveInvalidEmails := len(invalidEmails) > 0; haveInvalidEmails {
+		return fmt.Errorf(customErrors["invalidEmail"].Message, errors.New("invalidEmail"), strings.Join(invalidEmails, ", "))
+	}
+	return nil
+}
+
+// emailParam returns the normalized email in the URL parameter name, responding with an error if it is invalid
+func emailParam(c *gin.Context, name string) (string, bool) {
+	email := normalizeEmail(c.Param(name))
+	if err := checkEmails([]string{email}); err != nil {
+		respondWithError(c, err)
+		return "", false
+	}
+	return email, true
+}
+
+// prepareStudentRegistration normalizes and deduplicates the emails of a registration and checks they are valid.
+// The normalized registration is returned even when it is invalid, e.g. for auditing
+func prepareStudentRegistration(studentRegistrationData models.StudentRegistrationData[string]) (models.StudentRegistrationData[string], error) {
+	studentRegistrationData.Teacher = normalizeEmail(studentRegistrationData.Teacher)
+	studentRegistrationData.Students = removeDuplicateStr(normalizeEmails(studentRegistrationData.Students))
+
+	allEmails := append(append([]string{}, studentRegistrationData.Students...), studentRegistrationData.Teacher)
+	return studentRegistrationData, checkEmails(allEmails)
+}
+
+// prepareNotification normalizes the teacher of a notification and the students it @mentions and checks they are valid.
+// The normalized data is returned even when it is invalid, e.g. for auditing
+func prepareNotification(retrieveForNotificationsData models.RetrieveForNotificationsData) (models.RetrieveForNotificationsProcessedData[string], error) {
+	retrieveForNotificationsProcessedData := models.RetrieveForNotificationsProcessedData[string] {
+		Teacher: normalizeEmail(retrieveForNotificationsData.Teacher),
+		Students: getMentionedStudents(retrieveForNotificationsData.Notification),
+		Notification: retrieveForNotificationsData.Notification,
+	}
+
+	allEmails := append(append([]string{}, retrieveForNotificationsProcessedData.Students...), retrieveForNotificationsProcessedData.Teacher)
+	return retrieveForNotificationsProcessedData, checkEmails(allEmails)
+}
+
+// Internal errors are logged with the request id rather than returned, as they may reveal implementation details
+const internalErrorMessage = "Something went wrong on our end. Please quote the request id when reporting this problem"
+
+func respondWithError(c *gin.Context, err error) {
+	httpStatus, body := newErrorResponse(c, err)
+	c.IndentedJSON(httpStatus, body)
+}
+
+// newErrorResponse returns the status and body to answer err with, for handlers that report errors inside
+// another body. Internal errors are logged here
+func newErrorResponse(c *gin.Context, err error) (int, errorResponseBody) {
+	httpStatus, message := getStatusAndMessage(err)
+
+	if httpStatus >= 500 {
+		requestLogger(c).Error("Request failed", "status", httpStatus, "error", err)
+		message = internalErrorMessage
+	}
+
+	reason := ""
+	if httpStatus == http.StatusForbidden {
+		reason = errors.Unwrap(err).Error()
+	}
+
+	return httpStatus, errorResponseBody{Message: message, Reason: reason, RequestID: getRequestID(c)}
+}
+
 func getStatusAndMessage(err error) (int, string) {
 	var httpStatus int
 	message := err.Error()
@@ -17,13 +17,17 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/pashagolub/pgxmock/v3"
+	"go.opentelemetry.io/otel/trace/noop"
 )
 
 var testRouter *gin.Engine
 
 func init() {
-	testRouter = router()
+	cfg := testConfig()
+	cfg.RateLimit.Enabled = false // Every test request comes from the same client
+	testRouter = router(cfg, noop.NewTracerProvider(), newRateLimitStore(cfg.RateLimit))
 }
 
 
@@ -38,8 +42,8 @@ type registerStudentsTestCase struct {
 
 func OneRegisterStudentTest(t *testing.T, router *gin.Engine, testCase registerStudentsTestCase) {
 	// First, add expected queries and results to the mock DB
-	teacher := testCase.body.Teacher
-	students := testCase.body.Students
+	teacher := normalizeEmail(testCase.body.Teacher)
+	students := removeDuplicateStr(normalizeEmails(testCase.body.Students))
 
 	mock, err := pgxmock.NewConn()
 	if err != nil {
@@ -78,11 +82,15 @@ func OneRegisterStudentTest(t *testing.T, router *gin.Engine, testCase registerS
 	}
 
 	if noRequestErrors {
+		mock.ExpectBegin()
 		for _, student := range students {
 			mock.ExpectQuery(regexp.QuoteMeta("INSERT INTO teacher_student_relationship(teacher, student) VALUES ($1, $2)")).WithArgs(teacher, student).WillReturnRows(pgxmock.NewRows([]string{"id", "teacher", "student"}))
 		}
+		mock.ExpectCommit()
 	}
 
+	addRecordAuditEventQuery(mock, "register", append([]string{teacher}, students...), testCase.wantCode, testCase.wantResponseBody)
+
 	models.DB = mock // assign the mock connection's pointer to models.DB so it can be used by the API endpoints
 
 	// Now, we make the API call
@@ -136,7 +144,7 @@ func TestRegisterStudents(t *testing.T) {
 			models.StudentRegistrationData[bool]{Teacher: true, Students: []bool{true, true}},
 			[]bool{false, false},
 			customErrors["invalidDataType"].Status,
-			errorResponseBody{ fmt.Errorf(customErrors["invalidDataType"].Message, errors.New("invalidDataType")).Error() },
+			errorResponseBody{Message: fmt.Errorf(customErrors["invalidDataType"].Message, errors.New("invalidDataType")).Error() },
 		},		
         {
 			"One or more invalid emails", 
@@ -144,7 +152,7 @@ func TestRegisterStudents(t *testing.T) {
 			models.StudentRegistrationData[bool]{Teacher: true, Students: []bool{true, true}},
 			[]bool{false, false},
 			customErrors["invalidEmail"].Status,
-			errorResponseBody{ fmt.Errorf(customErrors["invalidEmail"].Message, errors.New("invalidEmail"), strings.Join([]string{"'jerrygmail.com'", "'tomgmail.com'"}, ", ")).Error() },
+			errorResponseBody{Message: fmt.Errorf(customErrors["invalidEmail"].Message, errors.New("invalidEmail"), strings.Join([]string{"'jerrygmail.com'", "'tomgmail.com'"}, ", ")).Error() },
 		},
         {
 			"One or more invalid emails & non-existent email(s)", 
@@ -152,7 +160,7 @@ func TestRegisterStudents(t *testing.T) {
 			models.StudentRegistrationData[bool]{Teacher: false, Students: []bool{true, true}},
 			[]bool{false, false},
 			customErrors["invalidEmail"].Status,
-			errorResponseBody{ fmt.Errorf(customErrors["invalidEmail"].Message, errors.New("invalidEmail"), strings.Join([]string{"'jerrygmail.com'", "'tomgmail.com'"}, ", ")).Error() },
+			errorResponseBody{Message: fmt.Errorf(customErrors["invalidEmail"].Message, errors.New("invalidEmail"), strings.Join([]string{"'jerrygmail.com'", "'tomgmail.com'"}, ", ")).Error() },
 		},	
         {
 			"Missing email(s)", 
@@ -160,15 +168,23 @@ func TestRegisterStudents(t *testing.T) {
 			models.StudentRegistrationData[bool]{Teacher: false, Students: []bool{false, true}},
 			[]bool{false, false},
 			customErrors["invalidEmail"].Status,
-			errorResponseBody{ fmt.Errorf(customErrors["invalidEmail"].Message, errors.New("invalidEmail"), strings.Join([]string{"' '", "' '"}, ", ")).Error() },
+			errorResponseBody{Message: fmt.Errorf(customErrors["invalidEmail"].Message, errors.New("invalidEmail"), strings.Join([]string{"' '", "' '"}, ", ")).Error() },
 		},			
+        {
+			"Mixed case emails and case-insensitive duplicates", 
+			models.StudentRegistrationData[string]{Teacher: " Tom@Gmail.com", Students: []string{"Jerry@gmail.com", "jerry@GMAIL.com ", "spike@gmail.com"}},
+			models.StudentRegistrationData[bool]{Teacher: true, Students: []bool{true, true}},
+			[]bool{false, false},
+			204,
+			registerStudentsSuccessBody{},
+		},
 		{
 			"Non existent teacher email", 
 			models.StudentRegistrationData[string]{Teacher: "tom@gmail.com", Students: []string{"jerry@gmail.com", "spike@gmail.com"}},
 			models.StudentRegistrationData[bool]{Teacher: false, Students: []bool{true, true}},
 			[]bool{false, false},
 			models.CustomErrors["nonExistentTeacher"].Status,
-			errorResponseBody{ fmt.Errorf(models.CustomErrors["nonExistentTeacher"].Message, errors.New("nonExistentTeacher"), "tom@gmail.com").Error() },
+			errorResponseBody{Message: fmt.Errorf(models.CustomErrors["nonExistentTeacher"].Message, errors.New("nonExistentTeacher"), "tom@gmail.com").Error() },
 		},
 		{
 			"Non existent student emails", 
@@ -176,7 +192,7 @@ func TestRegisterStudents(t *testing.T) {
 			models.StudentRegistrationData[bool]{Teacher: true, Students: []bool{false, false}},
 			[]bool{false, false},
 			models.CustomErrors["nonExistentStudents"].Status,
-			errorResponseBody{ fmt.Errorf(models.CustomErrors["nonExistentStudents"].Message, errors.New("nonExistentStudents"), strings.Join([]string{"'jerry@gmail.com'", "'spike@gmail.com'"}, ", ")).Error() },
+			errorResponseBody{Message: fmt.Errorf(models.CustomErrors["nonExistentStudents"].Message, errors.New("nonExistentStudents"), strings.Join([]string{"'jerry@gmail.com'", "'spike@gmail.com'"}, ", ")).Error() },
 		},	
 		{
 			"Non existent student & teacher emails", 
@@ -184,7 +200,7 @@ func TestRegisterStudents(t *testing.T) {
 			models.StudentRegistrationData[bool]{Teacher: false, Students: []bool{false, true}},
 			[]bool{false, false},
 			models.CustomErrors["nonExistentTeacher&Students"].Status,
-			errorResponseBody{ fmt.Errorf(models.CustomErrors["nonExistentTeacher&Students"].Message, errors.New("nonExistentTeacher&Students"), "tom@gmail.com", strings.Join([]string{"'jerry@gmail.com'"}, ", ")).Error() },
+			errorResponseBody{Message: fmt.Errorf(models.CustomErrors["nonExistentTeacher&Students"].Message, errors.New("nonExistentTeacher&Students"), "tom@gmail.com", strings.Join([]string{"'jerry@gmail.com'"}, ", ")).Error() },
 		},	
         {
 			"Student(s) already registered with Teacher", 
@@ -192,7 +208,7 @@ func TestRegisterStudents(t *testing.T) {
 			models.StudentRegistrationData[bool]{Teacher: true, Students: []bool{true, true}},
 			[]bool{true, false},
 			models.CustomErrors["studentsAlreadyRegistered"].Status,
-			errorResponseBody{ fmt.Errorf(models.CustomErrors["studentsAlreadyRegistered"].Message, errors.New("studentsAlreadyRegistered"), strings.Join([]string{"'jerry@gmail.com'"}, ", "), "tom@gmail.com").Error() },
+			errorResponseBody{Message: fmt.Errorf(models.CustomErrors["studentsAlreadyRegistered"].Message, errors.New("studentsAlreadyRegistered"), strings.Join([]string{"'jerry@gmail.com'"}, ", "), "tom@gmail.com").Error() },
 		},			
     }
 
@@ -310,7 +326,7 @@ func TestCommonStudents(t *testing.T) {
 				"spike@gmail.com": {"tom@gmail.com"},
 			},
 			customErrors["invalidEmail"].Status,
-			errorResponseBody{ fmt.Errorf(customErrors["invalidEmail"].Message, errors.New("invalidEmail"), strings.Join([]string{"'tomgmail.com'"}, ", ")).Error() },		
+			errorResponseBody{Message: fmt.Errorf(customErrors["invalidEmail"].Message, errors.New("invalidEmail"), strings.Join([]string{"'tomgmail.com'"}, ", ")).Error() },		
 		},
         {
 			"One or more invalid emails & non-existent email(s)",
@@ -321,7 +337,7 @@ func TestCommonStudents(t *testing.T) {
 				"spike@gmail.com": {"tom@gmail.com"},
 			},
 			customErrors["invalidEmail"].Status,
-			errorResponseBody{ fmt.Errorf(customErrors["invalidEmail"].Message, errors.New("invalidEmail"), strings.Join([]string{"'tomgmail.com'"}, ", ")).Error() },
+			errorResponseBody{Message: fmt.Errorf(customErrors["invalidEmail"].Message, errors.New("invalidEmail"), strings.Join([]string{"'tomgmail.com'"}, ", ")).Error() },
 		},		
 		{
 			"Non existent teacher(s) email", 
@@ -332,7 +348,7 @@ func TestCommonStudents(t *testing.T) {
 				"spike@gmail.com": {"tom@gmail.com"},
 			},
 			models.CustomErrors["nonExistentTeachers"].Status,
-			errorResponseBody{ fmt.Errorf(models.CustomErrors["nonExistentTeachers"].Message, errors.New("nonExistentTeachers"), strings.Join([]string{"'tom@gmail.com'"}, ", ")).Error() },
+			errorResponseBody{Message: fmt.Errorf(models.CustomErrors["nonExistentTeachers"].Message, errors.New("nonExistentTeachers"), strings.Join([]string{"'tom@gmail.com'"}, ", ")).Error() },
 		},
     }
 
@@ -378,6 +394,8 @@ func OneSuspendStudentTest(t *testing.T, router *gin.Engine, testCase suspendStu
 		mock.ExpectQuery(regexp.QuoteMeta("UPDATE student SET suspended = true WHERE email = $1")).WithArgs(student).WillReturnRows(pgxmock.NewRows([]string{"student", "suspended"}))
 	}
 
+	addRecordAuditEventQuery(mock, "suspend", []string{normalizeEmail(student)}, testCase.wantCode, testCase.wantResponseBody)
+
 	models.DB = mock // assign the mock connection's pointer to models.DB so it can be used by the API endpoints
 
 	// Now, we make the API call
@@ -413,35 +431,35 @@ func TestSuspendStudent(t *testing.T) {
 			models.StudentSuspensionData[string]{},
 			models.StudentSuspensionData[bool]{Student: true},
 			customErrors["invalidDataType"].Status,
-			errorResponseBody{ fmt.Errorf(customErrors["invalidDataType"].Message, errors.New("invalidDataType")).Error() },
+			errorResponseBody{Message: fmt.Errorf(customErrors["invalidDataType"].Message, errors.New("invalidDataType")).Error() },
 		},		
         {
 			"Invalid student email", 
 			models.StudentSuspensionData[string]{Student: "jerrygmail.com"},
 			models.StudentSuspensionData[bool]{Student: true},
 			customErrors["invalidEmail"].Status,
-			errorResponseBody{ fmt.Errorf(customErrors["invalidEmail"].Message, errors.New("invalidEmail"), strings.Join([]string{"'jerrygmail.com'"}, ", ")).Error() },
+			errorResponseBody{Message: fmt.Errorf(customErrors["invalidEmail"].Message, errors.New("invalidEmail"), strings.Join([]string{"'jerrygmail.com'"}, ", ")).Error() },
 		},
         {
 			"Invalid and non-existent student email", 
 			models.StudentSuspensionData[string]{Student: "jerrygmail.com"},
 			models.StudentSuspensionData[bool]{Student: false},
 			customErrors["invalidEmail"].Status,
-			errorResponseBody{ fmt.Errorf(customErrors["invalidEmail"].Message, errors.New("invalidEmail"), strings.Join([]string{"'jerrygmail.com'"}, ", ")).Error() },
+			errorResponseBody{Message: fmt.Errorf(customErrors["invalidEmail"].Message, errors.New("invalidEmail"), strings.Join([]string{"'jerrygmail.com'"}, ", ")).Error() },
 		},	
         {
 			"Missing email(s)", 
 			models.StudentSuspensionData[string]{Student: " "},
 			models.StudentSuspensionData[bool]{Student: false},
 			customErrors["invalidEmail"].Status,
-			errorResponseBody{ fmt.Errorf(customErrors["invalidEmail"].Message, errors.New("invalidEmail"), strings.Join([]string{"' '"}, ", ")).Error() },
+			errorResponseBody{Message: fmt.Errorf(customErrors["invalidEmail"].Message, errors.New("invalidEmail"), strings.Join([]string{"' '"}, ", ")).Error() },
 		},			
 		{
 			"Non existent student email", 
 			models.StudentSuspensionData[string]{Student: "jerry@gmail.com"},
 			models.StudentSuspensionData[bool]{Student: false},
 			models.CustomErrors["nonExistentStudent"].Status,
-			errorResponseBody{ fmt.Errorf(models.CustomErrors["nonExistentStudent"].Message, errors.New("nonExistentStudent"), "jerry@gmail.com").Error() },
+			errorResponseBody{Message: fmt.Errorf(models.CustomErrors["nonExistentStudent"].Message, errors.New("nonExistentStudent"), "jerry@gmail.com").Error() },
 		},
     }
 
@@ -520,8 +538,14 @@ func OneRetrieveForNotificationsTest(t *testing.T, router *gin.Engine, testCase
 		for index, student := range candidateRecipients {
 			addCheckStudentSuspendedQuery(mock, student, suspendedStatus[index])
 		}
+
+		if successBody, sent := testCase.wantResponseBody.(retrieveForNotificationsSuccessBody); sent {
+			addRecordNotificationQuery(mock, teacher, testCase.body.Notification, successBody.Recipients)
+		}
 	}
 
+	addRecordAuditEventQuery(mock, "notify", append([]string{normalizeEmail(teacher)}, mentionedStudents...), testCase.wantCode, testCase.wantResponseBody)
+
 	models.DB = mock // assign the mock connection's pointer to models.DB so it can be used by the API endpoints
 
 	// Now, we make the API call
@@ -553,7 +577,7 @@ func TestRetrieveForNotifications(t *testing.T) {
 			models.RetrieveForNotificationsProcessedData[bool]{Teacher: true, Students: []bool{}},
 			[]bool{false, false},
 			200,
-			retrieveForNotificationsSuccessBody{[]string{ "nibbles@gmail.com", "spike@gmail.com"}},
+			retrieveForNotificationsSuccessBody{Recipients: []string{ "nibbles@gmail.com", "spike@gmail.com"}},
 		},		
         {
 			"All valid and existent emails, 1 mentioned student", 
@@ -563,7 +587,7 @@ func TestRetrieveForNotifications(t *testing.T) {
 			models.RetrieveForNotificationsProcessedData[bool]{Teacher: true, Students: []bool{true}},
 			[]bool{false, false, false},
 			200,
-			retrieveForNotificationsSuccessBody{[]string{"jerry@gmail.com", "nibbles@gmail.com", "spike@gmail.com"}},
+			retrieveForNotificationsSuccessBody{Recipients: []string{"jerry@gmail.com", "nibbles@gmail.com", "spike@gmail.com"}},
 		},
         {
 			"All valid and existent emails, 2 mentioned students", 
@@ -573,7 +597,7 @@ func TestRetrieveForNotifications(t *testing.T) {
 			models.RetrieveForNotificationsProcessedData[bool]{Teacher: true, Students: []bool{true, true}},
 			[]bool{false, false, false, false},
 			200,
-			retrieveForNotificationsSuccessBody{[]string{"jerry@gmail.com", "nibbles@gmail.com", "spike@gmail.com", "tyke@gmail.com"}},
+			retrieveForNotificationsSuccessBody{Recipients: []string{"jerry@gmail.com", "nibbles@gmail.com", "spike@gmail.com", "tyke@gmail.com"}},
 		},	
         {
 			"All valid and existent emails, no registered students", 
@@ -583,7 +607,7 @@ func TestRetrieveForNotifications(t *testing.T) {
 			models.RetrieveForNotificationsProcessedData[bool]{Teacher: true, Students: []bool{true}},
 			[]bool{false},
 			200,
-			retrieveForNotificationsSuccessBody{[]string{"jerry@gmail.com"}},
+			retrieveForNotificationsSuccessBody{Recipients: []string{"jerry@gmail.com"}},
 		},			
         {
 			"All valid and existent emails, duplicate students", 
@@ -593,7 +617,7 @@ func TestRetrieveForNotifications(t *testing.T) {
 			models.RetrieveForNotificationsProcessedData[bool]{Teacher: true, Students: []bool{true, true}},
 			[]bool{false, false, false, false, false},
 			200,
-			retrieveForNotificationsSuccessBody{[]string{"jerry@gmail.com", "nibbles@gmail.com", "spike@gmail.com", "tyke@gmail.com"}},
+			retrieveForNotificationsSuccessBody{Recipients: []string{"jerry@gmail.com", "nibbles@gmail.com", "spike@gmail.com", "tyke@gmail.com"}},
 		},	
         {
 			"All valid and existent emails, suspended mentioned student", 
@@ -603,7 +627,7 @@ func TestRetrieveForNotifications(t *testing.T) {
 			models.RetrieveForNotificationsProcessedData[bool]{Teacher: true, Students: []bool{true, true}},
 			[]bool{true, false, false, false},
 			200,
-			retrieveForNotificationsSuccessBody{[]string{"jerry@gmail.com", "nibbles@gmail.com", "spike@gmail.com"}},
+			retrieveForNotificationsSuccessBody{Recipients: []string{"jerry@gmail.com", "nibbles@gmail.com", "spike@gmail.com"}},
 		},
         {
 			"All valid and existent emails, suspended registered student", 
@@ -613,7 +637,7 @@ func TestRetrieveForNotifications(t *testing.T) {
 			models.RetrieveForNotificationsProcessedData[bool]{Teacher: true, Students: []bool{true, true}},
 			[]bool{false, false, false, true},
 			200,
-			retrieveForNotificationsSuccessBody{[]string{"jerry@gmail.com", "nibbles@gmail.com", "tyke@gmail.com"}},
+			retrieveForNotificationsSuccessBody{Recipients: []string{"jerry@gmail.com", "nibbles@gmail.com", "tyke@gmail.com"}},
 		},		
         {
 			"All valid and existent emails, suspended mentioned and registered student", 
@@ -623,7 +647,7 @@ func TestRetrieveForNotifications(t *testing.T) {
 			models.RetrieveForNotificationsProcessedData[bool]{Teacher: true, Students: []bool{true, true}},
 			[]bool{true, false, false, true},
 			200,
-			retrieveForNotificationsSuccessBody{[]string{"jerry@gmail.com", "nibbles@gmail.com"}},
+			retrieveForNotificationsSuccessBody{Recipients: []string{"jerry@gmail.com", "nibbles@gmail.com"}},
 		},				
         {
 			"Malformed JSON", 
@@ -633,7 +657,7 @@ func TestRetrieveForNotifications(t *testing.T) {
 			models.RetrieveForNotificationsProcessedData[bool]{Teacher: true, Students: []bool{true}},
 			[]bool{false, false, false},
 			customErrors["invalidDataType"].Status,
-			errorResponseBody{ fmt.Errorf(customErrors["invalidDataType"].Message, errors.New("invalidDataType")).Error() },
+			errorResponseBody{Message: fmt.Errorf(customErrors["invalidDataType"].Message, errors.New("invalidDataType")).Error() },
 		},		
         {
 			"One or more invalid emails", 
@@ -643,7 +667,7 @@ func TestRetrieveForNotifications(t *testing.T) {
 			models.RetrieveForNotificationsProcessedData[bool]{Teacher: true, Students: []bool{true}},
 			[]bool{false, false, false},
 			customErrors["invalidEmail"].Status,
-			errorResponseBody{ fmt.Errorf(customErrors["invalidEmail"].Message, errors.New("invalidEmail"), strings.Join([]string{"'jerrygmail.com'", "'tomgmail.om'"}, ", ")).Error() },
+			errorResponseBody{Message: fmt.Errorf(customErrors["invalidEmail"].Message, errors.New("invalidEmail"), strings.Join([]string{"'jerrygmail.com'", "'tomgmail.om'"}, ", ")).Error() },
 		},
         {
 			"Merged mentions", 
@@ -653,7 +677,7 @@ func TestRetrieveForNotifications(t *testing.T) {
 			models.RetrieveForNotificationsProcessedData[bool]{Teacher: true, Students: []bool{false}},
 			[]bool{false, false, false},
 			customErrors["invalidEmail"].Status,
-			errorResponseBody{ fmt.Errorf(customErrors["invalidEmail"].Message, errors.New("invalidEmail"), strings.Join([]string{"'jerry@gmail.com@nibbles@gmail.com'"}, ", ")).Error() },
+			errorResponseBody{Message: fmt.Errorf(customErrors["invalidEmail"].Message, errors.New("invalidEmail"), strings.Join([]string{"'jerry@gmail.com@nibbles@gmail.com'"}, ", ")).Error() },
 		},		
         {
 			"One or more invalid emails & non-existent email(s)", 
@@ -663,7 +687,7 @@ func TestRetrieveForNotifications(t *testing.T) {
 			models.RetrieveForNotificationsProcessedData[bool]{Teacher: true, Students: []bool{false}},
 			[]bool{false, false, false},
 			customErrors["invalidEmail"].Status,
-			errorResponseBody{ fmt.Errorf(customErrors["invalidEmail"].Message, errors.New("invalidEmail"), strings.Join([]string{"'jerrygmail.com'", "'tomgmail.com'"}, ", ")).Error() },
+			errorResponseBody{Message: fmt.Errorf(customErrors["invalidEmail"].Message, errors.New("invalidEmail"), strings.Join([]string{"'jerrygmail.com'", "'tomgmail.com'"}, ", ")).Error() },
 		},	
         {
 			"Missing teacher email", 
@@ -673,7 +697,7 @@ func TestRetrieveForNotifications(t *testing.T) {
 			models.RetrieveForNotificationsProcessedData[bool]{Teacher: true, Students: []bool{true}},
 			[]bool{false, false, false},
 			customErrors["invalidEmail"].Status,
-			errorResponseBody{ fmt.Errorf(customErrors["invalidEmail"].Message, errors.New("invalidEmail"), strings.Join([]string{"' '"}, ", ")).Error() },
+			errorResponseBody{Message: fmt.Errorf(customErrors["invalidEmail"].Message, errors.New("invalidEmail"), strings.Join([]string{"' '"}, ", ")).Error() },
 		},			
 		{
 			"Non existent teacher email", 
@@ -683,7 +707,7 @@ func TestRetrieveForNotifications(t *testing.T) {
 			models.RetrieveForNotificationsProcessedData[bool]{Teacher: false, Students: []bool{true}},
 			[]bool{false, false, false},
 			models.CustomErrors["nonExistentTeacher"].Status,
-			errorResponseBody{ fmt.Errorf(models.CustomErrors["nonExistentTeacher"].Message, errors.New("nonExistentTeacher"), "tom@gmail.com").Error() },
+			errorResponseBody{Message: fmt.Errorf(models.CustomErrors["nonExistentTeacher"].Message, errors.New("nonExistentTeacher"), "tom@gmail.com").Error() },
 		},
 		{
 			"Non existent student emails", 
@@ -693,7 +717,7 @@ func TestRetrieveForNotifications(t *testing.T) {
 			models.RetrieveForNotificationsProcessedData[bool]{Teacher: true, Students: []bool{false, false}},
 			[]bool{false, false, false},
 			models.CustomErrors["nonExistentStudents"].Status,
-			errorResponseBody{ fmt.Errorf(models.CustomErrors["nonExistentStudents"].Message, errors.New("nonExistentStudents"), strings.Join([]string{"'jerry@gmail.com'", "'spike@gmail.com'"}, ", ")).Error() },
+			errorResponseBody{Message: fmt.Errorf(models.CustomErrors["nonExistentStudents"].Message, errors.New("nonExistentStudents"), strings.Join([]string{"'jerry@gmail.com'", "'spike@gmail.com'"}, ", ")).Error() },
 		},	
 		{
 			"Non existent student & teacher emails", 
@@ -703,7 +727,7 @@ func TestRetrieveForNotifications(t *testing.T) {
 			models.RetrieveForNotificationsProcessedData[bool]{Teacher: false, Students: []bool{false}},
 			[]bool{false, false, false},
 			models.CustomErrors["nonExistentTeacher&Students"].Status,
-			errorResponseBody{ fmt.Errorf(models.CustomErrors["nonExistentTeacher&Students"].Message, errors.New("nonExistentTeacher&Students"), "tom@gmail.com", strings.Join([]string{"'jerry@gmail.com'"}, ", ")).Error() },
+			errorResponseBody{Message: fmt.Errorf(models.CustomErrors["nonExistentTeacher&Students"].Message, errors.New("nonExistentTeacher&Students"), "tom@gmail.com", strings.Join([]string{"'jerry@gmail.com'"}, ", ")).Error() },
 		},		
     }
 
@@ -712,4 +736,311 @@ func TestRetrieveForNotifications(t *testing.T) {
 			OneRetrieveForNotificationsTest(t, testRouter, tc)
 		})
 	}
-}
\ No newline at end of file
+}
+
+func TestPreviewNotification(t *testing.T) {
+	addRegisteredStudentsQuery := func(mock pgxmock.PgxConnIface, students []string) {
+		mock.ExpectQuery(regexp.QuoteMeta(`
+		SELECT array_agg(DISTINCT student) AS students
+		FROM teacher_student_relationship
+		WHERE teacher = $1
+		GROUP BY teacher
+	`)).WithArgs("tom@gmail.com").WillReturnRows(pgxmock.NewRows([]string{"students"}).AddRow(students))
+	}
+
+	testCases := []routeTestCase{
+		{
+			"Registered, mentioned and suspended students are explained, and nothing is sent",
+			"POST", "/api/retrievefornotifications", models.RetrieveForNotificationsData{Teacher: "tom@gmail.com", Notification: "Hello @spike@gmail.com @tyke@gmail.com", DryRun: true},
+			func(mock pgxmock.PgxConnIface) {
+				addCheckTeacherExistsQuery(mock, "tom@gmail.com", true)
+				addCheckStudentExistsQueries(mock, []string{"spike@gmail.com", "tyke@gmail.com"}, []bool{true, true})
+				addRegisteredStudentsQuery(mock, []string{"jerry@gmail.com", "spike@gmail.com"})
+				addCheckStudentSuspendedQuery(mock, "spike@gmail.com", false)
+				addCheckStudentSuspendedQuery(mock, "tyke@gmail.com", true)
+				addCheckStudentSuspendedQuery(mock, "jerry@gmail.com", false)
+				addCheckStudentSuspendedQuery(mock, "spike@gmail.com", false)
+				addRecordAuditEventQuery(mock, "notify_preview", []string{"tom@gmail.com", "spike@gmail.com", "tyke@gmail.com"}, 200, nil)
+			},
+			200,
+			map[string]any{
+				"recipients": []any{"jerry@gmail.com", "spike@gmail.com"},
+				"students": []any{
+					map[string]any{"email": "jerry@gmail.com", "registered": true, "mentioned": false, "suspended": false},
+					map[string]any{"email": "spike@gmail.com", "registered": true, "mentioned": true, "suspended": false},
+					map[string]any{"email": "tyke@gmail.com", "registered": false, "mentioned": true, "suspended": true},
+				},
+			},
+		},
+		{
+			"Unknown teacher",
+			"POST", "/api/retrievefornotifications", models.RetrieveForNotificationsData{Teacher: "nobody@gmail.com", Notification: "Hello", DryRun: true},
+			func(mock pgxmock.PgxConnIface) {
+				addCheckTeacherExistsQuery(mock, "nobody@gmail.com", false)
+				addRecordAuditEventQuery(mock, "notify_preview", []string{"nobody@gmail.com"}, models.CustomErrors["nonExistentTeacher"].Status, nil)
+			},
+			models.CustomErrors["nonExistentTeacher"].Status,
+			errorResponseBody{Message: fmt.Errorf(models.CustomErrors["nonExistentTeacher"].Message, errors.New("nonExistentTeacher"), "nobody@gmail.com").Error()},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.testCaseDesc, func(t *testing.T) {
+			runRouteTest(t, testRouter, testCase)
+		})
+	}
+}
+
+func TestNormalizeEmail(t *testing.T) {
+	testCases := []struct {
+		testCaseDesc string
+		email string
+		want string
+	}{
+		{"Already normalized", "tom@gmail.com", "tom@gmail.com"},
+		{"Uppercase local part and domain", "Tom@Gmail.COM", "tom@gmail.com"},
+		{"Surrounding whitespace", "  tom@gmail.com\t", "tom@gmail.com"},
+		{"Invalid email is left untouched", " tomgmail.com ", " tomgmail.com "},
+		{"Blank email is left untouched", " ", " "},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testCaseDesc, func(t *testing.T) {
+			if got := normalizeEmail(tc.email); got != tc.want {
+				t.Errorf("wrong normalized email:\nwant: %q\n got: %q", tc.want, got)
+			}
+		})
+	}
+}
+
+type updateStudentTestCase struct {
+	testCaseDesc string
+	id string
+	body models.StudentUpdateData
+	queryResult models.Student
+	previousEmail string
+	queryError error
+	wantCode int
+	wantResponseBody any
+}
+
+func OneUpdateStudentTest(t *testing.T, router *gin.Engine, testCase updateStudentTestCase) {
+	// First, add expected queries and results to the mock DB
+	id := testCase.id
+	email := normalizeEmail(testCase.body.Email)
+
+	mock, err := pgxmock.NewConn()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mock.Close(context.Background())
+
+	if validateID(id) && validateEmail(email) {
+		expectedQuery := mock.ExpectQuery(regexp.QuoteMeta(`
+		UPDATE student SET email = $1
+		FROM (SELECT id, email FROM student WHERE id = $2 FOR UPDATE) AS previous
+		WHERE student.id = previous.id
+		RETURNING student.id, student.email, COALESCE(student.suspended, false), previous.email
+	`)).WithArgs(email, id)
+
+		if testCase.queryError != nil {
+			expectedQuery.WillReturnError(testCase.queryError)
+		} else {
+			student := testCase.queryResult
+			expectedQuery.WillReturnRows(pgxmock.NewRows([]string{"id", "email", "suspended", "previous_email"}).AddRow(student.ID, student.Email, student.Suspended, testCase.previousEmail))
+		}
+	}
+
+	// Updated students are audited under their previous and new emails, and their id
+	auditTargets, auditTargetIDs := []string{}, []string{}
+	if validateID(id) {
+		auditTargets, auditTargetIDs = []string{email}, []string{id}
+		if testCase.previousEmail != "" {
+			auditTargets = []string{testCase.previousEmail, email}
+		}
+	}
+	if testCase.wantResponseBody == (errorResponseBody{Message: fmt.Errorf(customErrors["invalidDataType"].Message, errors.New("invalidDataType")).Error()}) {
+		auditTargets, auditTargetIDs = []string{}, []string{}
+	}
+	addRecordAuditEventQueryAs(mock, anonymousActor, "update_student", auditTargets, auditTargetIDs, testCase.wantCode)
+
+	models.DB = mock // assign the mock connection's pointer to models.DB so it can be used by the API endpoints
+
+	// Now, we make the API call
+    out, err := json.Marshal(testCase.body)
+    if err != nil {
+        log.Fatal(err)
+    }
+
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest("PATCH", "/api/students/"+url.PathEscape(id), bytes.NewBuffer(out))
+	if err != nil {
+		t.Fatalf("building request: %v", err)
+	}
+
+	router.ServeHTTP(recorder, request)
+
+	// make sure that all expectations were met
+	checkQueryExpectations(mock, t)
+	checkStatusAndResponse[models.Student](recorder, t, testCaseStruct{testCase.wantCode, testCase.wantResponseBody})
+}
+
+func TestUpdateStudent(t *testing.T) {
+	id := "0b0f8c3e-4a55-4b9e-9d0f-7f1a3c2b6e11"
+
+	testCases := []updateStudentTestCase{
+		{
+			"Valid id and new email", 
+			id,
+			models.StudentUpdateData{Email: "Jerry.Mouse@gmail.com"},
+			models.Student{ID: id, Email: "jerry.mouse@gmail.com", Suspended: false},
+			"jerry@gmail.com",
+			nil,
+			200,
+			models.Student{ID: id, Email: "jerry.mouse@gmail.com", Suspended: false},
+		},
+		{
+			"Malformed JSON", 
+			id,
+			models.StudentUpdateData{},
+			models.Student{},
+			"",
+			nil,
+			customErrors["invalidDataType"].Status,
+			errorResponseBody{Message: fmt.Errorf(customErrors["invalidDataType"].Message, errors.New("invalidDataType")).Error() },
+		},
+		{
+			"Invalid id", 
+			"jerry@gmail.com",
+			models.StudentUpdateData{Email: "jerry.mouse@gmail.com"},
+			models.Student{},
+			"",
+			nil,
+			customErrors["invalidID"].Status,
+			errorResponseBody{Message: fmt.Errorf(customErrors["invalidID"].Message, errors.New("invalidID"), "jerry@gmail.com").Error() },
+		},
+		{
+			"Invalid email", 
+			id,
+			models.StudentUpdateData{Email: "jerrygmail.com"},
+			models.Student{},
+			"",
+			nil,
+			customErrors["invalidEmail"].Status,
+			errorResponseBody{Message: fmt.Errorf(customErrors["invalidEmail"].Message, errors.New("invalidEmail"), strings.Join([]string{"'jerrygmail.com'"}, ", ")).Error() },
+		},
+		{
+			"Non existent student id", 
+			id,
+			models.StudentUpdateData{Email: "jerry.mouse@gmail.com"},
+			models.Student{},
+			"",
+			pgx.ErrNoRows,
+			models.CustomErrors["nonExistentStudentID"].Status,
+			errorResponseBody{Message: fmt.Errorf(models.CustomErrors["nonExistentStudentID"].Message, errors.New("nonExistentStudentID"), id).Error() },
+		},
+		{
+			"Email already in use", 
+			id,
+			models.StudentUpdateData{Email: "spike@gmail.com"},
+			models.Student{},
+			"",
+			&pgconn.PgError{Code: "23505"},
+			models.CustomErrors["emailAlreadyInUse"].Status,
+			errorResponseBody{Message: fmt.Errorf(models.CustomErrors["emailAlreadyInUse"].Message, errors.New("emailAlreadyInUse"), "spike@gmail.com").Error() },
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testCaseDesc, func(t *testing.T) {
+			OneUpdateStudentTest(t, testRouter, tc)
+		})
+	}
+}
+
+func TestStudentTeachers(t *testing.T) {
+	commonTeachersQuery := regexp.QuoteMeta(`
+		SELECT teacher
+		FROM teacher_student_relationship
+		WHERE student = ANY($1)
+		GROUP BY teacher
+		HAVING COUNT(DISTINCT student) = $2
+		ORDER BY teacher
+	`)
+
+	testCases := []routeTestCase{
+		{
+			"Look a student up by email, to find their id",
+			"GET", "/api/students/Jerry@Gmail.com", nil,
+			func(mock pgxmock.PgxConnIface) {
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT id, email, COALESCE(suspended, false) FROM student WHERE email = $1")).WithArgs("jerry@gmail.com").
+					WillReturnRows(pgxmock.NewRows([]string{"id", "email", "suspended"}).AddRow("5f0c1b7e-3c2a-4d8e-9b1a-2f6d7e8c9a01", "jerry@gmail.com", true))
+			},
+			200,
+			map[string]any{"id": "5f0c1b7e-3c2a-4d8e-9b1a-2f6d7e8c9a01", "email": "jerry@gmail.com", "suspended": true},
+		},
+		{
+			"Look up a student that does not exist",
+			"GET", "/api/students/nobody@gmail.com", nil,
+			func(mock pgxmock.PgxConnIface) {
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT id, email, COALESCE(suspended, false) FROM student WHERE email = $1")).WithArgs("nobody@gmail.com").
+					WillReturnRows(pgxmock.NewRows([]string{"id", "email", "suspended"}))
+			},
+			models.CustomErrors["nonExistentStudent"].Status,
+			errorResponseBody{Message: fmt.Errorf(models.CustomErrors["nonExistentStudent"].Message, errors.New("nonExistentStudent"), "nobody@gmail.com").Error()},
+		},
+		{
+			"List a student's teachers",
+			"GET", "/api/students/Jerry@Gmail.com/teachers", nil,
+			func(mock pgxmock.PgxConnIface) {
+				addCheckStudentExistsQuery(mock, "jerry@gmail.com", true)
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT teacher FROM teacher_student_relationship WHERE student = $1 ORDER BY teacher")).WithArgs("jerry@gmail.com").
+					WillReturnRows(pgxmock.NewRows([]string{"teacher"}).AddRow("quacker@gmail.com").AddRow("tom@gmail.com"))
+			},
+			200,
+			map[string]any{"teachers": []any{"quacker@gmail.com", "tom@gmail.com"}},
+		},
+		{
+			"List the teachers of a student that does not exist",
+			"GET", "/api/students/nobody@gmail.com/teachers", nil,
+			func(mock pgxmock.PgxConnIface) {
+				addCheckStudentExistsQuery(mock, "nobody@gmail.com", false)
+			},
+			models.CustomErrors["nonExistentStudent"].Status,
+			errorResponseBody{Message: fmt.Errorf(models.CustomErrors["nonExistentStudent"].Message, errors.New("nonExistentStudent"), "nobody@gmail.com").Error()},
+		},
+		{
+			"Teachers common to several students",
+			"GET", "/api/commonteachers?student=jerry@gmail.com&student=Spike@Gmail.com&student=jerry@gmail.com", nil,
+			func(mock pgxmock.PgxConnIface) {
+				addCheckStudentExistsQueries(mock, []string{"jerry@gmail.com", "spike@gmail.com"}, []bool{true, true})
+				mock.ExpectQuery(commonTeachersQuery).WithArgs([]string{"jerry@gmail.com", "spike@gmail.com"}, 2).
+					WillReturnRows(pgxmock.NewRows([]string{"teacher"}).AddRow("tom@gmail.com"))
+			},
+			200,
+			map[string]any{"teachers": []any{"tom@gmail.com"}},
+		},
+		{
+			"Common teachers of students that do not exist",
+			"GET", "/api/commonteachers?student=jerry@gmail.com&student=nobody@gmail.com", nil,
+			func(mock pgxmock.PgxConnIface) {
+				addCheckStudentExistsQueries(mock, []string{"jerry@gmail.com", "nobody@gmail.com"}, []bool{true, false})
+			},
+			models.CustomErrors["nonExistentStudents"].Status,
+			errorResponseBody{Message: fmt.Errorf(models.CustomErrors["nonExistentStudents"].Message, errors.New("nonExistentStudents"), "'nobody@gmail.com'").Error()},
+		},
+		{
+			"Common teachers of an invalid email",
+			"GET", "/api/commonteachers?student=jerrygmail.com", nil,
+			nil,
+			customErrors["invalidEmail"].Status,
+			errorResponseBody{Message: fmt.Errorf(customErrors["invalidEmail"].Message, errors.New("invalidEmail"), "'jerrygmail.com'").Error()},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.testCaseDesc, func(t *testing.T) {
+			runRouteTest(t, testRouter, testCase)
+		})
+	}
+}
@@ -1,11 +1,18 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
 	"encoding/json"
+	"net/http"
 	"net/http/httptest"
+	"onecv-go-backend/models"
 	"testing"
 	"regexp"
 
+	"github.com/gin-gonic/gin"
 	"github.com/pashagolub/pgxmock/v3"
 	"github.com/google/go-cmp/cmp"
 )
@@ -50,12 +57,60 @@ func checkStatusAndResponse[registerStudentsSuccessBody any](recorder *httptest.
 		
 	} else {
 		responseBody := getResponseBody[errorResponseBody](recorder, t)
+		if responseBody.RequestID == "" || responseBody.RequestID != recorder.Header().Get(requestIDHeader) {
+			t.Errorf("error response does not carry the request id:\nheader: %v\n  body: %v", recorder.Header().Get(requestIDHeader), responseBody.RequestID)
+		}
+
+		responseBody.RequestID = "" // Generated per request, so it is left out of the expected bodies
 		if !cmp.Equal(responseBody, testCase.wantResponseBody) {
 			t.Errorf("wrong response body:\nwant: %s\n got: %s", testCase.wantResponseBody, responseBody)
 		}
 	}	
 }
 
+// routeTestCase is one request to a router, with the queries it is expected to make against a stub database
+type routeTestCase struct {
+	testCaseDesc string
+	method string
+	path string
+	body any
+	addExpectedQueries func(mock pgxmock.PgxConnIface)
+	wantCode int
+	// Success bodies are compared as decoded JSON
+	wantResponseBody any
+}
+
+func runRouteTest(t *testing.T, router *gin.Engine, testCase routeTestCase) {
+	mock, err := pgxmock.NewConn()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mock.Close(context.Background())
+
+	if testCase.addExpectedQueries != nil {
+		testCase.addExpectedQueries(mock)
+	}
+	models.DB = mock
+
+	var body bytes.Buffer
+	if testCase.body != nil {
+		if err := json.NewEncoder(&body).Encode(testCase.body); err != nil {
+			t.Fatalf("encoding body: %v", err)
+		}
+	}
+
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(testCase.method, testCase.path, &body)
+	if err != nil {
+		t.Fatalf("building request: %v", err)
+	}
+
+	router.ServeHTTP(recorder, request)
+
+	checkQueryExpectations(mock, t)
+	checkStatusAndResponse[map[string]any](recorder, t, testCaseStruct{testCase.wantCode, testCase.wantResponseBody})
+}
+
 func addCheckStudentExistsQuery(mock pgxmock.PgxConnIface, student string, studentExists bool) {
 	expectedStudentRow := pgxmock.NewRows([]string{"email"})
 	if (studentExists) {expectedStudentRow.AddRow(student)}
@@ -108,4 +163,28 @@ func addCheckStudentSuspendedQuery(mock pgxmock.PgxConnIface, student string, st
 	expectedStudentRow.AddRow(studentSuspended)
 
 	mock.ExpectQuery(regexp.QuoteMeta("SELECT suspended FROM student WHERE email = $1")).WithArgs(student).WillReturnRows(expectedStudentRow)	
-}
\ No newline at end of file
+}
+// Every notification that is sent is recorded with the recipients it was sent to
+func addRecordNotificationQuery(mock pgxmock.PgxConnIface, teacher string, notification string, recipients []string) {
+	mock.ExpectQuery(regexp.QuoteMeta("INSERT INTO notification(teacher, notification, recipients) VALUES ($1, $2, $3) RETURNING id")).
+		WithArgs(teacher, notification, recipients).WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(int64(1)))
+}
+
+// Every request to a mutating endpoint ends with an audit event. The payload hash and request id are not checked.
+// Requests whose body could not be parsed have no targets
+func addRecordAuditEventQuery(mock pgxmock.PgxConnIface, action string, targets []string, status int, wantResponseBody any) {
+	if wantResponseBody == (errorResponseBody{Message: fmt.Errorf(customErrors["invalidDataType"].Message, errors.New("invalidDataType")).Error()}) {
+		targets = []string{}
+	}
+
+	addRecordAuditEventQueryAs(mock, anonymousActor, action, targets, []string{}, status)
+}
+
+func addRecordAuditEventQueryAs(mock pgxmock.PgxConnIface, actor string, action string, targets []string, targetIDs []string, status int) {
+	mock.ExpectQuery(regexp.QuoteMeta(`
+		INSERT INTO audit_event(actor, action, target_emails, target_ids, payload_hash, outcome, status, request_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id
+	`)).WithArgs(actor, action, targets, targetIDs, pgxmock.AnyArg(), auditOutcome(status), status, pgxmock.AnyArg()).
+		WillReturnRows(pgxmock.NewRows([]string{"id"}).AddRow(int64(1)))
+}
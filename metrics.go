@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	httpRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "onecv_http_requests_total",
+		Help: "HTTP requests by method, route and status code.",
+	}, []string{"method", "route", "status"})
+
+	httpRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "onecv_http_request_duration_seconds",
+		Help:    "Time taken to handle HTTP requests, by method, route and status code.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	grpcCallsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "onecv_grpc_calls_total",
+		Help: "gRPC calls by method and status code.",
+	}, []string{"method", "code"})
+
+	grpcCallDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "onecv_grpc_call_duration_seconds",
+		Help:    "Time taken to handle gRPC calls, by method and status code.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "code"})
+)
+
+// Requests that match no route share one label, so random URLs cannot blow up the number of series
+const unmatchedRoute = "unmatched"
+
+// recordMetrics labels requests by route template (e.g. /api/students/:student) rather than by URL
+func recordMetrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		status := strconv.Itoa(c.Writer.Status())
+
+		httpRequestsTotal.WithLabelValues(c.Request.Method, route, status).Inc()
+		httpRequestDuration.WithLabelValues(c.Request.Method, route, status).Observe(time.Since(start).Seconds())
+	}
+}
+
+// recordGRPCMetrics labels calls by full method name, e.g. /onecv.v1.OneCV/SuspendStudent
+func recordGRPCMetrics() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, request any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+		response, err := handler(ctx, request)
+
+		code := status.Code(err).String()
+		grpcCallsTotal.WithLabelValues(info.FullMethod, code).Inc()
+		grpcCallDuration.WithLabelValues(info.FullMethod, code).Observe(time.Since(start).Seconds())
+		return response, err
+	}
+}
+
+func getMetrics() gin.HandlerFunc {
+	return gin.WrapH(promhttp.Handler())
+}
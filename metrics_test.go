@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"onecv-go-backend/models"
+	"strings"
+	"testing"
+
+	"github.com/pashagolub/pgxmock/v3"
+)
+
+func TestMetrics(t *testing.T) {
+	mock, err := pgxmock.NewConn()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mock.Close(context.Background())
+
+	addCheckTeachersExistsQueries(mock, []string{"butch@gmail.com"}, []bool{false})
+	models.DB = mock
+
+	// A request rejected by the models layer, so that both HTTP and database metrics are recorded
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest("GET", "/api/commonstudents?teacher=butch@gmail.com", nil)
+	if err != nil {
+		t.Fatalf("building request: %v", err)
+	}
+	testRouter.ServeHTTP(recorder, request)
+	checkQueryExpectations(mock, t)
+
+	recorder = httptest.NewRecorder()
+	request, err = http.NewRequest("GET", "/metrics", nil)
+	if err != nil {
+		t.Fatalf("building request: %v", err)
+	}
+	testRouter.ServeHTTP(recorder, request)
+
+	if recorder.Code != 200 {
+		t.Fatalf("wrong response code:\nwant: 200\n got: %v", recorder.Code)
+	}
+
+	for _, wantSeries := range []string{
+		`onecv_http_requests_total{method="GET",route="/api/commonstudents",status="400"}`,
+		`onecv_http_request_duration_seconds_count{method="GET",route="/api/commonstudents",status="400"}`,
+		`onecv_db_operations_total{operation="common_students",outcome="rejected"}`,
+		`onecv_db_operation_duration_seconds_count{operation="common_students"}`,
+	} {
+		if !strings.Contains(recorder.Body.String(), wantSeries) {
+			t.Errorf("metrics are missing %s", wantSeries)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+
+	"onecv-go-backend/migrations"
+)
+
+const migrateUsage = `usage: migrate <command>
+
+commands:
+  up            apply all pending migrations
+  down [steps]  revert the latest applied migration(s), 1 by default
+  status        list migrations and when they were applied`
+
+func runMigrateCommand(conn migrations.Conn, args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+	ctx := context.Background()
+
+	switch args[0] {
+	case "up":
+		applied, err := migrations.Up(ctx, conn)
+		for _, migration := range applied {
+			fmt.Fprintf(os.Stdout, "Applied %04d_%s\n", migration.Version, migration.Name)
+		}
+		if err == nil && len(applied) == 0 {
+			fmt.Fprintln(os.Stdout, "No pending migrations")
+		}
+		return err
+
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			var err error
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps < 1 {
+				return fmt.Errorf("steps must be a positive integer, got '%s'", args[1])
+			}
+		}
+
+		reverted, err := migrations.Down(ctx, conn, steps)
+		for _, migration := range reverted {
+			fmt.Fprintf(os.Stdout, "Reverted %04d_%s\n", migration.Version, migration.Name)
+		}
+		return err
+
+	case "status":
+		statuses, err := migrations.Status(ctx, conn)
+		if err != nil { return err }
+
+		for _, status := range statuses {
+			appliedAt := "pending"
+			if status.AppliedAt != nil {
+				appliedAt = "applied at " + status.AppliedAt.Format("2006-01-02 15:04:05 MST")
+			}
+			fmt.Fprintf(os.Stdout, "%04d_%s\t%s\n", status.Version, status.Name, appliedAt)
+		}
+		return nil
+
+	default:
+		return fmt.Errorf("unknown migrate command '%s'\n%s", args[0], migrateUsage)
+	}
+}
@@ -0,0 +1,246 @@
+package migrations
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+//go:embed sql/*.sql
+var files embed.FS
+
+// Migration files are named <version>_<name>.<up|down>.sql, e.g. 0001_init_database.up.sql
+var fileNamePattern = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// Arbitrary key shared by every instance, so only one of them migrates the database at a time
+const advisoryLockKey = 4121800
+
+type Conn interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+// Querier is the read-only subset of Conn, e.g. models.PgxIface
+type Querier interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+}
+
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+type MigrationStatus struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+// Load returns the embedded migrations sorted by version
+func Load() ([]Migration, error) {
+	entries, err := fs.ReadDir(files, "sql")
+	if err != nil { return nil, err }
+
+	migrationsByVersion := map[int]*Migration{}
+	for _, entry := range entries {
+		matches := fileNamePattern.FindStringSubmatch(entry.Name())
+		if matches == nil {
+			return nil, fmt.Errorf("migration file %s does not match <version>_<name>.<up|down>.sql", entry.Name())
+		}
+
+		version, _ := strconv.Atoi(matches[1])
+		contents, err := files.ReadFile("sql/" + entry.Name())
+		if err != nil { return nil, err }
+
+		migration, exists := migrationsByVersion[version]
+		if !exists {
+			migration = &Migration{Version: version, Name: matches[2]}
+			migrationsByVersion[version] = migration
+		} else if migration.Name != matches[2] {
+			return nil, fmt.Errorf("migration %04d has two names: %s and %s", version, migration.Name, matches[2])
+		}
+
+		if matches[3] == "up" {
+			migration.Up = string(contents)
+		} else {
+			migration.Down = string(contents)
+		}
+	}
+
+	migrations := []Migration{}
+	for _, migration := range migrationsByVersion {
+		if migration.Up == "" || migration.Down == "" {
+			return nil, fmt.Errorf("migration %04d_%s must have both an up and a down file", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+	return migrations, nil
+}
+
+// Status lists every known migration along with when it was applied (nil if pending)
+func Status(ctx context.Context, conn Conn) ([]MigrationStatus, error) {
+	migrations, err := Load()
+	if err != nil { return nil, err }
+
+	if err := createMigrationsTable(ctx, conn); err != nil { return nil, err }
+
+	appliedAt, err := getAppliedVersions(ctx, conn)
+	if err != nil { return nil, err }
+
+	statuses := []MigrationStatus{}
+	for _, migration := range migrations {
+		status := MigrationStatus{Migration: migration}
+		if timestamp, applied := appliedAt[migration.Version]; applied {
+			status.AppliedAt = &timestamp
+		}
+		statuses = append(statuses, status)
+	}
+
+	return statuses, nil
+}
+
+// Pending returns the migrations that have not been applied yet, in the order they will be applied
+func Pending(ctx context.Context, conn Conn) ([]Migration, error) {
+	statuses, err := Status(ctx, conn)
+	if err != nil { return nil, err }
+
+	pending := []Migration{}
+	for _, status := range statuses {
+		if status.AppliedAt == nil {
+			pending = append(pending, status.Migration)
+		}
+	}
+
+	return pending, nil
+}
+
+// Unapplied is a read-only version of Pending for health checks. Unlike Pending, it does not
+// create the schema_migrations table, so it fails if no migration has ever been applied
+func Unapplied(ctx context.Context, querier Querier) ([]Migration, error) {
+	migrations, err := Load()
+	if err != nil { return nil, err }
+
+	appliedAt, err := getAppliedVersions(ctx, querier)
+	if err != nil { return nil, err }
+
+	unapplied := []Migration{}
+	for _, migration := range migrations {
+		if _, applied := appliedAt[migration.Version]; !applied {
+			unapplied = append(unapplied, migration)
+		}
+	}
+
+	return unapplied, nil
+}
+
+// Up applies every pending migration, each in its own transaction, and returns the ones it applied
+func Up(ctx context.Context, conn Conn) ([]Migration, error) {
+	pending, err := Pending(ctx, conn)
+	if err != nil { return nil, err }
+
+	applied := []Migration{}
+	for _, migration := range pending {
+		ran, err := runInTransaction(ctx, conn, migration.Version, true, func(tx pgx.Tx) error {
+			if _, err := tx.Exec(ctx, migration.Up); err != nil { return err }
+
+			_, err := tx.Exec(ctx, "INSERT INTO schema_migrations(version, name) VALUES ($1, $2)", migration.Version, migration.Name)
+			return err
+		})
+		if err != nil {
+			return applied, fmt.Errorf("applying migration %04d_%s: %w", migration.Version, migration.Name, err)
+		}
+
+		if ran { applied = append(applied, migration) }
+	}
+
+	return applied, nil
+}
+
+// Down reverts the latest `steps` applied migrations, newest first, and returns the ones it reverted
+func Down(ctx context.Context, conn Conn, steps int) ([]Migration, error) {
+	statuses, err := Status(ctx, conn)
+	if err != nil { return nil, err }
+
+	reverted := []Migration{}
+	for index := len(statuses) - 1; index >= 0 && len(reverted) < steps; index-- {
+		migration := statuses[index].Migration
+		if statuses[index].AppliedAt == nil { continue }
+
+		ran, err := runInTransaction(ctx, conn, migration.Version, false, func(tx pgx.Tx) error {
+			if _, err := tx.Exec(ctx, migration.Down); err != nil { return err }
+
+			_, err := tx.Exec(ctx, "DELETE FROM schema_migrations WHERE version = $1", migration.Version)
+			return err
+		})
+		if err != nil {
+			return reverted, fmt.Errorf("reverting migration %04d_%s: %w", migration.Version, migration.Name, err)
+		}
+
+		if ran { reverted = append(reverted, migration) }
+	}
+
+	return reverted, nil
+}
+
+func createMigrationsTable(ctx context.Context, conn Conn) error {
+	_, err := conn.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INTEGER PRIMARY KEY,
+			name TEXT NOT NULL,
+			applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+		)
+	`)
+	return err
+}
+
+func getAppliedVersions(ctx context.Context, querier Querier) (map[int]time.Time, error) {
+	rows, err := querier.Query(ctx, "SELECT version, applied_at FROM schema_migrations")
+	if err != nil { return nil, err }
+	defer rows.Close()
+
+	appliedAt := map[int]time.Time{}
+	for rows.Next() {
+		var version int
+		var timestamp time.Time
+		if err := rows.Scan(&version, &timestamp); err != nil {
+			return nil, err
+		}
+		appliedAt[version] = timestamp
+	}
+
+	return appliedAt, rows.Err()
+}
+
+// runInTransaction runs fn while holding the migration lock. Another instance may have migrated
+// the database while we waited for the lock, so fn only runs if the version is still in the
+// expected state (pending when applying, applied when reverting). Reports whether fn ran.
+func runInTransaction(ctx context.Context, conn Conn, version int, wantPending bool, fn func(pgx.Tx) error) (bool, error) {
+	tx, err := conn.Begin(ctx)
+	if err != nil { return false, err }
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, "SELECT pg_advisory_xact_lock($1)", advisoryLockKey); err != nil {
+		return false, err
+	}
+
+	var applied bool
+	err = tx.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM schema_migrations WHERE version = $1)", version).Scan(&applied)
+	if err != nil { return false, err }
+	if applied == wantPending { return false, nil }
+
+	if err := fn(tx); err != nil { return false, err }
+
+	return true, tx.Commit(ctx)
+}
@@ -0,0 +1,69 @@
+package migrations
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/pashagolub/pgxmock/v3"
+)
+
+func TestLoad(t *testing.T) {
+	migrations, err := Load()
+	if err != nil {
+		t.Fatalf("loading migrations: %v", err)
+	}
+
+	if len(migrations) == 0 || migrations[0].Name != "init_database" {
+		t.Fatalf("migration 0001 must be init_database, got %v", migrations)
+	}
+
+	for index, migration := range migrations {
+		if migration.Version != index+1 {
+			t.Errorf("migration versions must be contiguous from 1:\nwant: %04d\n got: %04d_%s", index+1, migration.Version, migration.Name)
+		}
+	}
+}
+
+func TestUpAppliesOnlyPendingMigrations(t *testing.T) {
+	migrations, err := Load()
+	if err != nil {
+		t.Fatalf("loading migrations: %v", err)
+	}
+
+	mock, err := pgxmock.NewConn()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mock.Close(context.Background())
+
+	// Every migration but the first has yet to be applied
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS schema_migrations").WillReturnResult(pgxmock.NewResult("CREATE", 0))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT version, applied_at FROM schema_migrations")).
+		WillReturnRows(pgxmock.NewRows([]string{"version", "applied_at"}).AddRow(1, time.Now()))
+
+	for _, migration := range migrations[1:] {
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta("SELECT pg_advisory_xact_lock($1)")).WithArgs(advisoryLockKey).WillReturnResult(pgxmock.NewResult("SELECT", 1))
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT EXISTS (SELECT 1 FROM schema_migrations WHERE version = $1)")).
+			WithArgs(migration.Version).WillReturnRows(pgxmock.NewRows([]string{"exists"}).AddRow(false))
+		mock.ExpectExec(regexp.QuoteMeta(migration.Up)).WillReturnResult(pgxmock.NewResult("ALTER", 0))
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO schema_migrations(version, name) VALUES ($1, $2)")).
+			WithArgs(migration.Version, migration.Name).WillReturnResult(pgxmock.NewResult("INSERT", 1))
+		mock.ExpectCommit()
+	}
+
+	applied, err := Up(context.Background(), mock)
+	if err != nil {
+		t.Fatalf("applying migrations: %v", err)
+	}
+
+	if len(applied) != len(migrations)-1 {
+		t.Errorf("wrong number of applied migrations:\nwant: %v\n got: %v", len(migrations)-1, len(applied))
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
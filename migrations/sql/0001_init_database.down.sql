@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS teacher_student_relationship;
+DROP TABLE IF EXISTS student;
+DROP TABLE IF EXISTS teacher;
@@ -25,4 +25,4 @@ CREATE TABLE IF NOT EXISTS teacher_student_relationship (
             REFERENCES student(email)
             ON UPDATE CASCADE
             ON DELETE CASCADE
-);
\ No newline at end of file
+);
@@ -0,0 +1,22 @@
+ALTER TABLE teacher_student_relationship
+    DROP CONSTRAINT fk_teacher,
+    DROP CONSTRAINT fk_student;
+
+ALTER TABLE teacher ALTER COLUMN email TYPE TEXT;
+ALTER TABLE student ALTER COLUMN email TYPE TEXT;
+
+ALTER TABLE teacher_student_relationship
+    ALTER COLUMN teacher TYPE TEXT,
+    ALTER COLUMN student TYPE TEXT,
+
+    ADD CONSTRAINT fk_teacher
+        FOREIGN KEY (teacher)
+            REFERENCES teacher(email)
+            ON UPDATE CASCADE
+            ON DELETE CASCADE,
+
+    ADD CONSTRAINT fk_student
+        FOREIGN KEY (student)
+            REFERENCES student(email)
+            ON UPDATE CASCADE
+            ON DELETE CASCADE;
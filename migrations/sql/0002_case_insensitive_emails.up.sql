@@ -0,0 +1,57 @@
+-- Makes teacher and student emails case-insensitive identities.
+-- The migration aborts without changing anything if existing rows only differ
+-- by case/whitespace, so they can be merged by hand first.
+-- It is safe to run on databases where case_insensitive_emails.sql was already applied by hand.
+
+CREATE EXTENSION IF NOT EXISTS citext;
+
+DO $$
+DECLARE
+    collisions TEXT;
+BEGIN
+    SELECT string_agg(format('%s: %s', kind, emails), '; ')
+    INTO collisions
+    FROM (
+        SELECT 'teacher' AS kind, string_agg(email, ', ' ORDER BY email) AS emails
+        FROM teacher
+        GROUP BY lower(btrim(email))
+        HAVING count(*) > 1
+        UNION ALL
+        SELECT 'student' AS kind, string_agg(email, ', ' ORDER BY email) AS emails
+        FROM student
+        GROUP BY lower(btrim(email))
+        HAVING count(*) > 1
+    ) AS duplicates;
+
+    IF collisions IS NOT NULL THEN
+        RAISE EXCEPTION 'Case-colliding emails must be merged before migrating: %', collisions;
+    END IF;
+END $$;
+
+-- ON UPDATE CASCADE carries the normalized emails into teacher_student_relationship
+UPDATE teacher SET email = lower(btrim(email)) WHERE email <> lower(btrim(email));
+UPDATE student SET email = lower(btrim(email)) WHERE email <> lower(btrim(email));
+
+-- The foreign keys are recreated because their column types change together
+ALTER TABLE teacher_student_relationship
+    DROP CONSTRAINT IF EXISTS fk_teacher,
+    DROP CONSTRAINT IF EXISTS fk_student;
+
+ALTER TABLE teacher ALTER COLUMN email TYPE CITEXT;
+ALTER TABLE student ALTER COLUMN email TYPE CITEXT;
+
+ALTER TABLE teacher_student_relationship
+    ALTER COLUMN teacher TYPE CITEXT,
+    ALTER COLUMN student TYPE CITEXT,
+
+    ADD CONSTRAINT fk_teacher
+        FOREIGN KEY (teacher)
+            REFERENCES teacher(email)
+            ON UPDATE CASCADE
+            ON DELETE CASCADE,
+
+    ADD CONSTRAINT fk_student
+        FOREIGN KEY (student)
+            REFERENCES student(email)
+            ON UPDATE CASCADE
+            ON DELETE CASCADE;
@@ -0,0 +1,28 @@
+ALTER TABLE teacher_student_relationship
+    DROP CONSTRAINT fk_teacher,
+    DROP CONSTRAINT fk_student;
+
+ALTER TABLE teacher
+    DROP CONSTRAINT teacher_pkey,
+    DROP CONSTRAINT teacher_email_key,
+    DROP COLUMN id,
+    ADD PRIMARY KEY (email);
+
+ALTER TABLE student
+    DROP CONSTRAINT student_pkey,
+    DROP CONSTRAINT student_email_key,
+    DROP COLUMN id,
+    ADD PRIMARY KEY (email);
+
+ALTER TABLE teacher_student_relationship
+    ADD CONSTRAINT fk_teacher
+        FOREIGN KEY (teacher)
+            REFERENCES teacher(email)
+            ON UPDATE CASCADE
+            ON DELETE CASCADE,
+
+    ADD CONSTRAINT fk_student
+        FOREIGN KEY (student)
+            REFERENCES student(email)
+            ON UPDATE CASCADE
+            ON DELETE CASCADE;
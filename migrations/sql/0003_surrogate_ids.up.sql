@@ -0,0 +1,41 @@
+-- Gives teachers and students a stable UUID identity, keeping email as a unique attribute.
+-- teacher_student_relationship keeps referencing the unique emails (ON UPDATE CASCADE), so existing email-based queries are unaffected.
+-- Emails stay the foreign keys on purpose: every endpoint identifies teachers and students by email, so referencing
+-- ids would add a join to each query for no gain, and the cascade already keeps relationships intact when an email
+-- changes. The id is what stays stable for clients.
+-- It is safe to run on databases where surrogate_ids.sql was already applied by hand: the id columns are kept
+-- and the constraints recreated.
+
+-- The foreign keys depend on the email primary keys, so they are recreated against the unique constraints
+ALTER TABLE teacher_student_relationship
+    DROP CONSTRAINT IF EXISTS fk_teacher,
+    DROP CONSTRAINT IF EXISTS fk_student;
+
+ALTER TABLE teacher
+    DROP CONSTRAINT teacher_pkey,
+    DROP CONSTRAINT IF EXISTS teacher_email_key,
+    ADD COLUMN IF NOT EXISTS id UUID NOT NULL DEFAULT gen_random_uuid(),
+    ALTER COLUMN email SET NOT NULL,
+    ADD PRIMARY KEY (id),
+    ADD CONSTRAINT teacher_email_key UNIQUE (email);
+
+ALTER TABLE student
+    DROP CONSTRAINT student_pkey,
+    DROP CONSTRAINT IF EXISTS student_email_key,
+    ADD COLUMN IF NOT EXISTS id UUID NOT NULL DEFAULT gen_random_uuid(),
+    ALTER COLUMN email SET NOT NULL,
+    ADD PRIMARY KEY (id),
+    ADD CONSTRAINT student_email_key UNIQUE (email);
+
+ALTER TABLE teacher_student_relationship
+    ADD CONSTRAINT fk_teacher
+        FOREIGN KEY (teacher)
+            REFERENCES teacher(email)
+            ON UPDATE CASCADE
+            ON DELETE CASCADE,
+
+    ADD CONSTRAINT fk_student
+        FOREIGN KEY (student)
+            REFERENCES student(email)
+            ON UPDATE CASCADE
+            ON DELETE CASCADE;
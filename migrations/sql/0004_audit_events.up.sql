@@ -0,0 +1,20 @@
+-- Records who did what through the mutating endpoints. Rows are only ever inserted.
+CREATE TABLE audit_event (
+    id BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    occurred_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    -- The authenticated principal's subject, or 'anonymous' when auth is disabled
+    actor TEXT NOT NULL,
+    -- register, suspend, notify or update_student
+    action TEXT NOT NULL,
+    target_emails TEXT[] NOT NULL DEFAULT '{}',
+    -- Hex encoded SHA-256 of the request body, so payloads can be matched without storing them
+    payload_hash TEXT NOT NULL,
+    -- ok, rejected (a 4xx response) or error (a 5xx response)
+    outcome TEXT NOT NULL,
+    status INTEGER NOT NULL,
+    request_id TEXT NOT NULL
+);
+
+CREATE INDEX audit_event_occurred_at_idx ON audit_event (occurred_at DESC);
+CREATE INDEX audit_event_actor_idx ON audit_event (actor, occurred_at DESC);
+CREATE INDEX audit_event_target_emails_idx ON audit_event USING GIN (target_emails);
@@ -0,0 +1,12 @@
+-- Token buckets shared by every instance when RATE_LIMIT_STORE=postgres. Losing them on a crash only
+-- refills every bucket, so the table is not written to the WAL. Buckets idle for longer than the slowest
+-- route takes to refill are full again, and are deleted periodically by the server.
+CREATE UNLOGGED TABLE rate_limit_bucket (
+    -- The route and the client, e.g. 'POST /api/register|user:tom@gmail.com', 'POST /api/register|apiKey:reporting'
+    -- or 'GET /api/commonstudents|ip:203.0.113.7'. Each IP address also has a bucket shared by every route, '*|ip:203.0.113.7'
+    key TEXT PRIMARY KEY,
+    tokens DOUBLE PRECISION NOT NULL,
+    -- Whether the last request took a token
+    allowed BOOLEAN NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL
+);
@@ -0,0 +1,3 @@
+DROP INDEX teacher_student_relationship_student_idx;
+DROP INDEX student_email_trgm_idx;
+DROP INDEX student_email_prefix_idx;
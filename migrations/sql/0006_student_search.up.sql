@@ -0,0 +1,10 @@
+-- Indexes for GET /api/students?q=, which matches student emails by prefix or by trigram similarity.
+-- Emails are stored lowercase since 0002, and the indexes are on the same expression the search uses.
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX student_email_prefix_idx ON student (lower(email::text) text_pattern_ops);
+CREATE INDEX student_email_trgm_idx ON student USING GIN (lower(email::text) gin_trgm_ops);
+
+-- UNIQUE(teacher, student) only serves lookups by teacher. This one serves the teachers of a student
+-- (GET /api/students/:email/teachers, GET /api/commonteachers)
+CREATE INDEX teacher_student_relationship_student_idx ON teacher_student_relationship (student, teacher);
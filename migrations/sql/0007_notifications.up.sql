@@ -0,0 +1,17 @@
+-- Every notification sent through the API, for reporting. Recipients are those resolved at send time.
+CREATE TABLE notification (
+    id BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    teacher CITEXT NOT NULL,
+    notification TEXT NOT NULL,
+    recipients CITEXT[] NOT NULL DEFAULT '{}',
+    sent_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+
+    CONSTRAINT fk_teacher
+        FOREIGN KEY (teacher)
+            REFERENCES teacher(email)
+            ON UPDATE CASCADE
+            ON DELETE CASCADE
+);
+
+CREATE INDEX notification_sent_at_idx ON notification (sent_at);
+CREATE INDEX notification_teacher_idx ON notification (teacher, sent_at);
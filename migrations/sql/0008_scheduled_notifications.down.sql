@@ -0,0 +1,7 @@
+DROP INDEX notification_pending_idx;
+DELETE FROM notification WHERE status <> 'sent';
+ALTER TABLE notification
+    ALTER COLUMN sent_at SET NOT NULL,
+    DROP COLUMN mentions,
+    DROP COLUMN send_at,
+    DROP COLUMN status;
@@ -0,0 +1,9 @@
+-- Notifications can be scheduled with a send_at. They stay pending, with no sent_at or recipients, until the
+-- scheduler resolves their recipients at send time. The students they @mention are kept for that
+ALTER TABLE notification
+    ADD COLUMN status TEXT NOT NULL DEFAULT 'sent' CHECK (status IN ('pending', 'sent', 'cancelled', 'failed')),
+    ADD COLUMN send_at TIMESTAMPTZ,
+    ADD COLUMN mentions CITEXT[] NOT NULL DEFAULT '{}',
+    ALTER COLUMN sent_at DROP NOT NULL;
+
+CREATE INDEX notification_pending_idx ON notification (send_at) WHERE status = 'pending';
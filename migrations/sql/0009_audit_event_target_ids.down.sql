@@ -0,0 +1,2 @@
+DROP INDEX audit_event_target_ids_idx;
+ALTER TABLE audit_event DROP COLUMN target_ids;
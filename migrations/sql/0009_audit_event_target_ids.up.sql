@@ -0,0 +1,5 @@
+-- The ids of the records an event was about, e.g. the student whose email was changed, so that an event can be found
+-- whichever email the record had at the time
+ALTER TABLE audit_event ADD COLUMN target_ids TEXT[] NOT NULL DEFAULT '{}';
+
+CREATE INDEX audit_event_target_ids_idx ON audit_event USING GIN (target_ids);
@@ -0,0 +1,99 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+type AuditEvent struct {
+	ID           int64     `json:"id"`
+	OccurredAt   time.Time `json:"occurredAt"`
+	Actor        string    `json:"actor"`
+	Action       string    `json:"action"`
+	TargetEmails []string  `json:"targetEmails"`
+	TargetIDs    []string  `json:"targetIds"`
+	PayloadHash  string    `json:"payloadHash"`
+	Outcome      string    `json:"outcome"`
+	Status       int       `json:"status"`
+	RequestID    string    `json:"requestId"`
+}
+
+// AuditEventFilter narrows ListAuditEvents down. Zero values match every event
+type AuditEventFilter struct {
+	Actor       string
+	Action      string
+	TargetEmail string
+	TargetID    string
+	Outcome     string
+	Since       time.Time
+	Until       time.Time
+	Page
+}
+
+func RecordAuditEvent(ctx context.Context, event AuditEvent) (err error) {
+	ctx, finishOperation := startOperation(ctx, "record_audit_event")
+	defer func() { finishOperation(err) }()
+
+	var id int64
+	return DB.QueryRow(ctx, `
+		INSERT INTO audit_event(actor, action, target_emails, target_ids, payload_hash, outcome, status, request_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id
+	`, event.Actor, event.Action, event.TargetEmails, event.TargetIDs, event.PayloadHash, event.Outcome, event.Status, event.RequestID).Scan(&id)
+}
+
+// ListAuditEvents returns the page of events matching filter, most recent first, and whether there are more
+func ListAuditEvents(ctx context.Context, filter AuditEventFilter) (_ []AuditEvent, hasMore bool, err error) {
+	ctx, finishOperation := startOperation(ctx, "list_audit_events")
+	defer func() { finishOperation(err) }()
+
+	conditions := []string{}
+	args := []any{}
+	addCondition := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.Actor != "" { addCondition("actor = $%d", filter.Actor) }
+	if filter.Action != "" { addCondition("action = $%d", filter.Action) }
+	if filter.TargetEmail != "" { addCondition("$%d = ANY(target_emails)", filter.TargetEmail) }
+	if filter.TargetID != "" { addCondition("$%d = ANY(target_ids)", filter.TargetID) }
+	if filter.Outcome != "" { addCondition("outcome = $%d", filter.Outcome) }
+	if !filter.Since.IsZero() { addCondition("occurred_at >= $%d", filter.Since) }
+	if !filter.Until.IsZero() { addCondition("occurred_at < $%d", filter.Until) }
+
+	where := ""
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+	args = append(args, filter.Limit+1, filter.Offset)
+
+	rows, err := DB.Query(ctx, fmt.Sprintf(`
+		SELECT id, occurred_at, actor, action, target_emails, target_ids, payload_hash, outcome, status, request_id
+		FROM audit_event
+		%s
+		ORDER BY occurred_at DESC, id DESC
+		LIMIT $%d OFFSET $%d
+	`, where, len(args)-1, len(args)), args...)
+
+	if err != nil { return nil, false, err }
+	defer rows.Close()
+
+	events := []AuditEvent{}
+	for rows.Next() {
+		var event AuditEvent
+		err := rows.Scan(&event.ID, &event.OccurredAt, &event.Actor, &event.Action, &event.TargetEmails, &event.TargetIDs, &event.PayloadHash, &event.Outcome, &event.Status, &event.RequestID)
+		if err != nil {
+			return nil, false, err
+		}
+		events = append(events, event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, false, err
+	}
+
+	events, hasMore = trimPage(events, filter.Page)
+	return events, hasMore, nil
+}
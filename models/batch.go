@@ -0,0 +1,85 @@
+package models
+
+import (
+	"context"
+)
+
+// Batched lookups for callers that resolve many teachers or students at once, e.g. GraphQL queries.
+// Each takes one query whatever the number of emails. Emails that do not exist are left out of the results
+
+// GetExistingTeachers returns which of teachers exist
+func GetExistingTeachers(ctx context.Context, teachers []string) (_ map[string]bool, err error) {
+	ctx, finishOperation := startOperation(ctx, "batch_teachers")
+	defer func() { finishOperation(err) }()
+
+	rows, err := DB.Query(ctx, "SELECT email FROM teacher WHERE email = ANY($1)", teachers)
+	if err != nil { return nil, err }
+	defer rows.Close()
+
+	existingTeachers := map[string]bool{}
+	for rows.Next() {
+		var teacher string
+		if err := rows.Scan(&teacher); err != nil { return nil, err }
+
+		existingTeachers[teacher] = true
+	}
+	if err := rows.Err(); err != nil { return nil, err }
+
+	return existingTeachers, nil
+}
+
+// GetStudentSuspensions returns whether each of students that exists is suspended
+func GetStudentSuspensions(ctx context.Context, students []string) (_ map[string]bool, err error) {
+	ctx, finishOperation := startOperation(ctx, "batch_suspensions")
+	defer func() { finishOperation(err) }()
+
+	rows, err := DB.Query(ctx, "SELECT email, COALESCE(suspended, false) FROM student WHERE email = ANY($1)", students)
+	if err != nil { return nil, err }
+	defer rows.Close()
+
+	suspensions := map[string]bool{}
+	for rows.Next() {
+		var student string
+		var suspended bool
+		if err := rows.Scan(&student, &suspended); err != nil { return nil, err }
+
+		suspensions[student] = suspended
+	}
+	if err := rows.Err(); err != nil { return nil, err }
+
+	return suspensions, nil
+}
+
+// GetStudentsOfTeachers returns the students registered to each of teachers, in alphabetical order
+func GetStudentsOfTeachers(ctx context.Context, teachers []string) (_ map[string][]string, err error) {
+	ctx, finishOperation := startOperation(ctx, "batch_teacher_students")
+	defer func() { finishOperation(err) }()
+
+	return getRegistrations(ctx, "SELECT teacher, student FROM teacher_student_relationship WHERE teacher = ANY($1) ORDER BY student", teachers)
+}
+
+// GetTeachersOfStudents returns the teachers each of students is registered to, in alphabetical order
+func GetTeachersOfStudents(ctx context.Context, students []string) (_ map[string][]string, err error) {
+	ctx, finishOperation := startOperation(ctx, "batch_student_teachers")
+	defer func() { finishOperation(err) }()
+
+	return getRegistrations(ctx, "SELECT student, teacher FROM teacher_student_relationship WHERE student = ANY($1) ORDER BY teacher", students)
+}
+
+// getRegistrations groups the second column of a two column query by its first
+func getRegistrations(ctx context.Context, sql string, emails []string) (map[string][]string, error) {
+	rows, err := DB.Query(ctx, sql, emails)
+	if err != nil { return nil, err }
+	defer rows.Close()
+
+	registrations := map[string][]string{}
+	for rows.Next() {
+		var email, registeredEmail string
+		if err := rows.Scan(&email, &registeredEmail); err != nil { return nil, err }
+
+		registrations[email] = append(registrations[email], registeredEmail)
+	}
+	if err := rows.Err(); err != nil { return nil, err }
+
+	return registrations, nil
+}
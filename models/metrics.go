@@ -0,0 +1,133 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var (
+	operationsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "onecv_db_operations_total",
+		Help: "Database operations by outcome: ok, rejected (e.g. a non-existent student) or error.",
+	}, []string{"operation", "outcome"})
+
+	operationDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "onecv_db_operation_duration_seconds",
+		Help:    "Time taken by database operations, including every query they issue.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"operation"})
+
+	queriesTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "onecv_db_queries_total",
+		Help: "SQL queries issued, by the operation that issued them.",
+	}, []string{"operation"})
+
+	queryErrorsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "onecv_db_query_errors_total",
+		Help: "SQL queries that failed, by the operation that issued them.",
+	}, []string{"operation"})
+
+	queryDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "onecv_db_query_duration_seconds",
+		Help:    "Time taken by individual SQL queries, by the operation that issued them.",
+		Buckets: []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+	}, []string{"operation"})
+)
+
+// Queries issued outside of an operation, e.g. migrations and health checks
+const noOperation = "none"
+
+type operationKey struct{}
+type queryStartKey struct{}
+
+// startOperation labels ctx with the operation so that its queries can be attributed to it.
+// The returned function records the operation's outcome and duration
+func startOperation(ctx context.Context, operation string) (context.Context, func(error)) {
+	start := time.Now()
+
+	return context.WithValue(ctx, operationKey{}, operation), func(err error) {
+		operationDuration.WithLabelValues(operation).Observe(time.Since(start).Seconds())
+		operationsTotal.WithLabelValues(operation, operationOutcome(err)).Inc()
+	}
+}
+
+func operationFromContext(ctx context.Context) string {
+	if operation, ok := ctx.Value(operationKey{}).(string); ok {
+		return operation
+	}
+	return noOperation
+}
+
+// Errors created from CustomErrors are the client's fault, anything else is ours
+func operationOutcome(err error) string {
+	if err == nil {
+		return "ok"
+	}
+
+	errorCode := errors.Unwrap(err)
+	if errorCode != nil {
+		if _, isCustomError := CustomErrors[errorCode.Error()]; isCustomError {
+			return "rejected"
+		}
+	}
+	return "error"
+}
+
+// QueryTracer records metrics for every query run on a real connection and, when Tracer is set,
+// a span that is a child of the span in the query's context. Install it with pgx.ConnConfig.Tracer
+type QueryTracer struct {
+	Tracer trace.Tracer
+}
+
+func (queryTracer QueryTracer) TraceQueryStart(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	if queryTracer.Tracer != nil {
+		operation := operationFromContext(ctx)
+		ctx, _ = queryTracer.Tracer.Start(ctx, operation+" "+sqlCommand(data.SQL),
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				semconv.DBSystemPostgreSQL,
+				semconv.DBOperation(operation),
+				semconv.DBStatement(data.SQL),
+			),
+		)
+	}
+	return context.WithValue(ctx, queryStartKey{}, time.Now())
+}
+
+func (queryTracer QueryTracer) TraceQueryEnd(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryEndData) {
+	operation := operationFromContext(ctx)
+
+	if queryTracer.Tracer != nil {
+		span := trace.SpanFromContext(ctx)
+		if data.Err != nil {
+			span.RecordError(data.Err)
+			span.SetStatus(codes.Error, data.Err.Error())
+		}
+		span.End()
+	}
+
+	queriesTotal.WithLabelValues(operation).Inc()
+	if start, ok := ctx.Value(queryStartKey{}).(time.Time); ok {
+		queryDuration.WithLabelValues(operation).Observe(time.Since(start).Seconds())
+	}
+	if data.Err != nil {
+		queryErrorsTotal.WithLabelValues(operation).Inc()
+	}
+}
+
+// sqlCommand returns the statement's leading keyword, e.g. SELECT, for naming spans
+func sqlCommand(sql string) string {
+	if fields := strings.Fields(sql); len(fields) > 0 {
+		return strings.ToUpper(fields[0])
+	}
+	return "SQL"
+}
@@ -21,11 +21,29 @@ var CustomErrors = map[string]customError{
 	"nonExistentStudents" : {"%w: The email(s) %v do(es) not exist as student(s)", 400},
 	"nonExistentTeacher&Students": {"%w: '%v' does not exist as a teacher and %v do(es) not exist as student(s)", 400},
 	"studentsAlreadyRegistered": {"%w: Student(s) %v has/have already been registered with the teacher '%v'", 409},
+	"nonExistentStudentID": {"%w: No student has the id '%v'", 404},
+	"emailAlreadyInUse": {"%w: The email '%v' is already in use", 409},
+	"teacherAlreadyExists": {"%w: The email '%v' already exists as a teacher", 409},
+	"studentAlreadyExists": {"%w: The email '%v' already exists as a student", 409},
+	"nonExistentScheduledNotification": {"%w: No notification waiting to be sent has the id '%v'", 404},
 }
 
-func checkTeacherExists(teacher string) (bool, error) {
+// isCustomError tells the errors the models report about the data, e.g. emails that do not exist, from
+// errors raised by the database
+func isCustomError(err error) bool {
+	errorCode := errors.Unwrap(err)
+	if errorCode == nil { return false }
+
+	_, exists := CustomErrors[errorCode.Error()]
+	return exists
+}
+
+// SQLSTATE raised by Postgres when a UNIQUE constraint (e.g. on email) is violated
+const uniqueViolationCode = "23505"
+
+func checkTeacherExists(ctx context.Context, teacher string) (bool, error) {
 	var email string
-	err := DB.QueryRow(context.Background(), "SELECT email FROM teacher WHERE email = $1", teacher).Scan(&email)
+	err := DB.QueryRow(ctx, "SELECT email FROM teacher WHERE email = $1", teacher).Scan(&email)
 
 	if err == pgx.ErrNoRows {
 		return false, nil
@@ -36,12 +54,12 @@ func checkTeacherExists(teacher string) (bool, error) {
 	return true, nil
 }
 
-func checkTeachersExist(teachers []string) ([]string, error) {
+func checkTeachersExist(ctx context.Context, teachers []string) ([]string, error) {
 	nonExistentTeachers := []string{}
 
 	for _, teacher := range teachers {
 		// Check if teacher's email has been registered
-		teacherExists, err := checkTeacherExists(teacher)
+		teacherExists, err := checkTeacherExists(ctx, teacher)
 		if err != nil { return []string{}, err }
 
 		if !teacherExists {
@@ -52,9 +70,9 @@ func checkTeachersExist(teachers []string) ([]string, error) {
 	return nonExistentTeachers, nil
 }
 
-func checkStudentExists(student string) (bool, error) {
+func checkStudentExists(ctx context.Context, student string) (bool, error) {
 	var email string
-	err := DB.QueryRow(context.Background(), "SELECT email FROM student WHERE email = $1", student).Scan(&email)
+	err := DB.QueryRow(ctx, "SELECT email FROM student WHERE email = $1", student).Scan(&email)
 
 	if err == pgx.ErrNoRows {
 		return false, nil
@@ -65,12 +83,12 @@ func checkStudentExists(student string) (bool, error) {
 	return true, nil
 }
 
-func checkStudentsExist(students []string) ([]string, error) {
+func checkStudentsExist(ctx context.Context, students []string) ([]string, error) {
 	nonExistentStudents := []string{}
 
 	for _, student := range students {
 		// Check if student's email has been registered
-		studentExists, err := checkStudentExists(student)
+		studentExists, err := checkStudentExists(ctx, student)
 		if err != nil { return []string{}, err }
 
 		if !studentExists {
@@ -81,13 +99,13 @@ func checkStudentsExist(students []string) ([]string, error) {
 	return nonExistentStudents, nil
 }
 
-func checkTeacherStudentsExist(teacher string, students []string) error {
+func checkTeacherStudentsExist(ctx context.Context, teacher string, students []string) error {
 	var err error
 
-	teacherExists, err := checkTeacherExists(teacher)
+	teacherExists, err := checkTeacherExists(ctx, teacher)
 	if err != nil { return err }
 	
-	nonExistentStudents, err := checkStudentsExist(students)
+	nonExistentStudents, err := checkStudentsExist(ctx, students)
 	if err != nil { return err }
 
 	if !teacherExists && len(nonExistentStudents) > 0 {
@@ -105,12 +123,12 @@ func checkTeacherStudentsExist(teacher string, students []string) error {
 	return nil
 }
 
-func checkTeacherStudentRelationshipsExist(teacher string, students []string) ([]string, error) {
+func checkTeacherStudentRelationshipsExist(ctx context.Context, teacher string, students []string) ([]string, error) {
 	existentStudentTeacherRelationships := []string{}
 	for _, student := range students {
 		// Check if the teacher, student relationship exists
 		var relationshipID string
-		err := DB.QueryRow(context.Background(), "SELECT student FROM teacher_student_relationship WHERE teacher = $1 AND student = $2", teacher, student).Scan(&relationshipID)
+		err := DB.QueryRow(ctx, "SELECT student FROM teacher_student_relationship WHERE teacher = $1 AND student = $2", teacher, student).Scan(&relationshipID)
 	
 		if err == pgx.ErrNoRows {
 			//Do nothing
@@ -124,9 +142,9 @@ func checkTeacherStudentRelationshipsExist(teacher string, students []string) ([
 	return existentStudentTeacherRelationships, nil	
 }
 
-func checkStudentSuspended(student string) (bool, error) {
+func checkStudentSuspended(ctx context.Context, student string) (bool, error) {
 	var suspended bool
-	err := DB.QueryRow(context.Background(), "SELECT suspended FROM student WHERE email = $1", student).Scan(&suspended)
+	err := DB.QueryRow(ctx, "SELECT suspended FROM student WHERE email = $1", student).Scan(&suspended)
 
 	if err != nil {
 		return true, err
@@ -135,6 +153,20 @@ func checkStudentSuspended(student string) (bool, error) {
 	return suspended, nil
 }
 
+// Runs an INSERT ... ON CONFLICT DO NOTHING RETURNING ... statement and reports whether a row was inserted
+func insertIfNotExists(ctx context.Context, db rowQuerier, sql string, args ...any) (bool, error) {
+	var returned string
+	err := db.QueryRow(ctx, sql, args...).Scan(&returned)
+
+	if err == pgx.ErrNoRows {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
 func removeDuplicateStr(strSlice []string) []string {
     allKeys := make(map[string]bool)
     list := []string{}
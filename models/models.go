@@ -7,16 +7,24 @@ import (
 	"slices"
 	"sort"
 	"errors"
+	"time"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 )
 
 var DB PgxIface
 
 type PgxIface interface {
-	Close(context.Context) error
+	Ping(ctx context.Context) error
 	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
 	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+// rowQuerier is implemented by both DB and the transactions it begins
+type rowQuerier interface {
+	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
 }
 
 type StudentRegistrationData[T any] struct {
@@ -24,38 +32,49 @@ type StudentRegistrationData[T any] struct {
 	Students []T `json:"students" binding:"required"`
 }
 
-func RegisterStudents(studentRegistrationData StudentRegistrationData[string]) error {
+func RegisterStudents(ctx context.Context, studentRegistrationData StudentRegistrationData[string]) (err error) {
+	ctx, finishOperation := startOperation(ctx, "register")
+	defer func() { finishOperation(err) }()
+
 	teacher := studentRegistrationData.Teacher
 	students := studentRegistrationData.Students
 
-	err := checkTeacherStudentsExist(teacher, students)
+	err = checkTeacherStudentsExist(ctx, teacher, students)
 	if err != nil { return err }
 
-	existentStudentTeacherRelationships, err := checkTeacherStudentRelationshipsExist(teacher, students)
+	existentStudentTeacherRelationships, err := checkTeacherStudentRelationshipsExist(ctx, teacher, students)
 	if err != nil { return err }
 	if len(existentStudentTeacherRelationships) > 0 {
 		return fmt.Errorf(CustomErrors["studentsAlreadyRegistered"].Message, errors.New("studentsAlreadyRegistered"), strings.Join(existentStudentTeacherRelationships, ", "), teacher)
 	}
 	
+	// The students are registered in one transaction, so a failure part way leaves none of them registered
+	tx, err := DB.Begin(ctx)
+	if err != nil { return err }
+	defer tx.Rollback(ctx)
+
 	for _, student := range students {
-		rows, err := DB.Query(context.Background(), "INSERT INTO teacher_student_relationship(teacher, student) VALUES ($1, $2)", teacher, student)
+		rows, err := tx.Query(ctx, "INSERT INTO teacher_student_relationship(teacher, student) VALUES ($1, $2)", teacher, student)
 		if err != nil { return err }
 
 		rows.Close()
 	}
 
-	return nil
+	return tx.Commit(ctx)
 }
 
-func GetCommonStudents(teachers []string) ([]string, error) {
-	nonExistentTeachers, err := checkTeachersExist(teachers)
+func GetCommonStudents(ctx context.Context, teachers []string) (_ []string, err error) {
+	ctx, finishOperation := startOperation(ctx, "common_students")
+	defer func() { finishOperation(err) }()
+
+	nonExistentTeachers, err := checkTeachersExist(ctx, teachers)
 	if err != nil { return nil, err }
 
 	if len(nonExistentTeachers) > 0 {
 		return nil, fmt.Errorf(CustomErrors["nonExistentTeachers"].Message, errors.New("nonExistentTeachers"), strings.Join(nonExistentTeachers, ", "))
 	}
 
-	rows, err := DB.Query(context.Background(), `
+	rows, err := DB.Query(ctx, `
 		SELECT student, array_agg(DISTINCT teacher) AS teachers
 		FROM teacher_student_relationship
 		WHERE teacher = ANY($1)
@@ -93,16 +112,19 @@ type StudentSuspensionData[T any] struct {
 	Student T `json:"student" binding:"required"`
 }
 
-func SuspendStudent(studentSuspensionData StudentSuspensionData[string]) error {
+func SuspendStudent(ctx context.Context, studentSuspensionData StudentSuspensionData[string]) (err error) {
+	ctx, finishOperation := startOperation(ctx, "suspend")
+	defer func() { finishOperation(err) }()
+
 	student := studentSuspensionData.Student
 
-	studentExists, err := checkStudentExists(student)
+	studentExists, err := checkStudentExists(ctx, student)
 	if err != nil { return err }
 	if !studentExists {
 		return fmt.Errorf(CustomErrors["nonExistentStudent"].Message, errors.New("nonExistentStudent"), student)
 	}
 
-	rows, err := DB.Query(context.Background(), "UPDATE student SET suspended = true WHERE email = $1", student)
+	rows, err := DB.Query(ctx, "UPDATE student SET suspended = true WHERE email = $1", student)
 	if err != nil { return err }
 
 	rows.Close()
@@ -110,25 +132,258 @@ func SuspendStudent(studentSuspensionData StudentSuspensionData[string]) error {
 	return nil
 }
 
+func UnsuspendStudent(ctx context.Context, studentSuspensionData StudentSuspensionData[string]) (err error) {
+	ctx, finishOperation := startOperation(ctx, "unsuspend")
+	defer func() { finishOperation(err) }()
+
+	student := studentSuspensionData.Student
+
+	studentExists, err := checkStudentExists(ctx, student)
+	if err != nil { return err }
+	if !studentExists {
+		return fmt.Errorf(CustomErrors["nonExistentStudent"].Message, errors.New("nonExistentStudent"), student)
+	}
+
+	rows, err := DB.Query(ctx, "UPDATE student SET suspended = false WHERE email = $1", student)
+	if err != nil { return err }
+
+	rows.Close()
+
+	return nil
+}
+
+func GetStudentSuspension(ctx context.Context, student string) (suspended bool, err error) {
+	ctx, finishOperation := startOperation(ctx, "get_suspension")
+	defer func() { finishOperation(err) }()
+
+	err = DB.QueryRow(ctx, "SELECT COALESCE(suspended, false) FROM student WHERE email = $1", student).Scan(&suspended)
+	if err == pgx.ErrNoRows {
+		return false, fmt.Errorf(CustomErrors["nonExistentStudent"].Message, errors.New("nonExistentStudent"), student)
+	} else if err != nil {
+		return false, err
+	}
+
+	return suspended, nil
+}
+
+// GetStudent returns a student by email, with the id that identifies them across email changes
+func GetStudent(ctx context.Context, email string) (_ Student, err error) {
+	ctx, finishOperation := startOperation(ctx, "get_student")
+	defer func() { finishOperation(err) }()
+
+	var student Student
+	err = DB.QueryRow(ctx, "SELECT id, email, COALESCE(suspended, false) FROM student WHERE email = $1", email).Scan(&student.ID, &student.Email, &student.Suspended)
+	if err == pgx.ErrNoRows {
+		return Student{}, fmt.Errorf(CustomErrors["nonExistentStudent"].Message, errors.New("nonExistentStudent"), email)
+	} else if err != nil {
+		return Student{}, err
+	}
+
+	return student, nil
+}
+
+// GetTeacherStudents returns the students registered to teacher, in alphabetical order
+func GetTeacherStudents(ctx context.Context, teacher string) (_ []string, err error) {
+	ctx, finishOperation := startOperation(ctx, "teacher_students")
+	defer func() { finishOperation(err) }()
+
+	teacherExists, err := checkTeacherExists(ctx, teacher)
+	if err != nil { return nil, err }
+	if !teacherExists {
+		return nil, fmt.Errorf(CustomErrors["nonExistentTeacher"].Message, errors.New("nonExistentTeacher"), teacher)
+	}
+
+	rows, err := DB.Query(ctx, "SELECT student FROM teacher_student_relationship WHERE teacher = $1 ORDER BY student", teacher)
+	if err != nil { return nil, err }
+
+	students, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil { return nil, err }
+
+	return students, nil
+}
+
+// GetStudentTeachers returns the teachers student is registered to, in alphabetical order
+func GetStudentTeachers(ctx context.Context, student string) (_ []string, err error) {
+	ctx, finishOperation := startOperation(ctx, "student_teachers")
+	defer func() { finishOperation(err) }()
+
+	studentExists, err := checkStudentExists(ctx, student)
+	if err != nil { return nil, err }
+	if !studentExists {
+		return nil, fmt.Errorf(CustomErrors["nonExistentStudent"].Message, errors.New("nonExistentStudent"), student)
+	}
+
+	rows, err := DB.Query(ctx, "SELECT teacher FROM teacher_student_relationship WHERE student = $1 ORDER BY teacher", student)
+	if err != nil { return nil, err }
+
+	teachers, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil { return nil, err }
+
+	return teachers, nil
+}
+
+// GetCommonTeachers returns the teachers every one of students is registered to, in alphabetical order.
+// It is the inverse of GetCommonStudents
+func GetCommonTeachers(ctx context.Context, students []string) (_ []string, err error) {
+	ctx, finishOperation := startOperation(ctx, "common_teachers")
+	defer func() { finishOperation(err) }()
+
+	nonExistentStudents, err := checkStudentsExist(ctx, students)
+	if err != nil { return nil, err }
+
+	if len(nonExistentStudents) > 0 {
+		return nil, fmt.Errorf(CustomErrors["nonExistentStudents"].Message, errors.New("nonExistentStudents"), strings.Join(nonExistentStudents, ", "))
+	}
+
+	rows, err := DB.Query(ctx, `
+		SELECT teacher
+		FROM teacher_student_relationship
+		WHERE student = ANY($1)
+		GROUP BY teacher
+		HAVING COUNT(DISTINCT student) = $2
+		ORDER BY teacher
+	`, students, len(students))
+	if err != nil { return nil, err }
+
+	commonTeachers, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil { return nil, err }
+
+	return commonTeachers, nil
+}
+
+// ListTeachers returns every teacher's email, in alphabetical order
+func ListTeachers(ctx context.Context) (_ []string, err error) {
+	ctx, finishOperation := startOperation(ctx, "list_teachers")
+	defer func() { finishOperation(err) }()
+
+	rows, err := DB.Query(ctx, "SELECT email FROM teacher ORDER BY email")
+	if err != nil { return nil, err }
+
+	teachers, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil { return nil, err }
+
+	return teachers, nil
+}
+
+type Student struct {
+	ID        string `json:"id"`
+	Email     string `json:"email"`
+	Suspended bool   `json:"suspended"`
+}
+
+type StudentUpdateData struct {
+	Email string `json:"email" binding:"required"`
+}
+
+// UpdateStudentEmail changes the email of the student with the given id, returning the student and the email they had
+func UpdateStudentEmail(ctx context.Context, id string, studentUpdateData StudentUpdateData) (_ Student, previousEmail string, err error) {
+	ctx, finishOperation := startOperation(ctx, "update_student")
+	defer func() { finishOperation(err) }()
+
+	email := studentUpdateData.Email
+
+	var student Student
+	err = DB.QueryRow(ctx, `
+		UPDATE student SET email = $1
+		FROM (SELECT id, email FROM student WHERE id = $2 FOR UPDATE) AS previous
+		WHERE student.id = previous.id
+		RETURNING student.id, student.email, COALESCE(student.suspended, false), previous.email
+	`, email, id).Scan(&student.ID, &student.Email, &student.Suspended, &previousEmail)
+
+	var pgErr *pgconn.PgError
+	if err == pgx.ErrNoRows {
+		return Student{}, "", fmt.Errorf(CustomErrors["nonExistentStudentID"].Message, errors.New("nonExistentStudentID"), id)
+	} else if errors.As(err, &pgErr) && pgErr.Code == uniqueViolationCode {
+		return Student{}, "", fmt.Errorf(CustomErrors["emailAlreadyInUse"].Message, errors.New("emailAlreadyInUse"), email)
+	} else if err != nil {
+		return Student{}, "", err
+	}
+
+	return student, previousEmail, nil
+}
+
 type RetrieveForNotificationsData struct {
 	Teacher  string   `json:"teacher" binding:"required"`
 	Notification string `json:"notification" binding:"required"`
+	// Explain who the notification would reach, without sending it
+	DryRun bool `json:"dryRun"`
+	// Send the notification at this time rather than now
+	SendAt *time.Time `json:"sendAt,omitempty"`
 }
 
 type RetrieveForNotificationsProcessedData[T any] struct {
 	Teacher  T   `json:"teacher" binding:"required"`
 	Students []T `json:"students" binding:"required"`
+	// The notification's text, recorded when it is sent
+	Notification string `json:"-"`
+}
+
+// RetrieveForNotifications returns the recipients of a notification and records it as sent
+func RetrieveForNotifications(ctx context.Context, retrieveForNotificationsProcessedData RetrieveForNotificationsProcessedData[string]) (_ []string, err error) {
+	ctx, finishOperation := startOperation(ctx, "notify")
+	defer func() { finishOperation(err) }()
+
+	recipients, err := getNotificationRecipients(ctx, retrieveForNotificationsProcessedData)
+	if err != nil { return nil, err }
+
+	var id int64
+	err = DB.QueryRow(ctx, "INSERT INTO notification(teacher, notification, recipients) VALUES ($1, $2, $3) RETURNING id",
+		retrieveForNotificationsProcessedData.Teacher, retrieveForNotificationsProcessedData.Notification, recipients).Scan(&id)
+	if err != nil { return nil, err }
+
+	return recipients, nil
+}
+
+// GetNotificationRecipients returns who a notification would reach, without recording it
+func GetNotificationRecipients(ctx context.Context, retrieveForNotificationsProcessedData RetrieveForNotificationsProcessedData[string]) (_ []string, err error) {
+	ctx, finishOperation := startOperation(ctx, "notification_recipients")
+	defer func() { finishOperation(err) }()
+
+	return getNotificationRecipients(ctx, retrieveForNotificationsProcessedData)
+}
+
+// NotificationRecipient explains why a student would, or would not, receive a notification
+type NotificationRecipient struct {
+	Email      string `json:"email"`
+	Registered bool   `json:"registered"`
+	Mentioned  bool   `json:"mentioned"`
+	// Suspended students are excluded, whether they are registered or @mentioned
+	Suspended  bool   `json:"suspended"`
+}
+
+// ExplainNotificationRecipients returns every student a notification concerns, in alphabetical order, with why
+// they would or would not receive it. Nothing is recorded
+func ExplainNotificationRecipients(ctx context.Context, retrieveForNotificationsProcessedData RetrieveForNotificationsProcessedData[string]) (_ []NotificationRecipient, err error) {
+	ctx, finishOperation := startOperation(ctx, "explain_notification")
+	defer func() { finishOperation(err) }()
+
+	return explainNotificationRecipients(ctx, retrieveForNotificationsProcessedData)
 }
 
-func RetrieveForNotifications(retrieveForNotificationsProcessedData RetrieveForNotificationsProcessedData[string]) ([]string, error) {
+// getNotificationRecipients returns the unsuspended students who are registered to the teacher or @mentioned,
+// in alphabetical order
+func getNotificationRecipients(ctx context.Context, retrieveForNotificationsProcessedData RetrieveForNotificationsProcessedData[string]) ([]string, error) {
+	explained, err := explainNotificationRecipients(ctx, retrieveForNotificationsProcessedData)
+	if err != nil { return nil, err }
+
+	recipients := []string{}
+	for _, student := range explained {
+		if !student.Suspended {
+			recipients = append(recipients, student.Email)
+		}
+	}
+	return recipients, nil
+}
+
+func explainNotificationRecipients(ctx context.Context, retrieveForNotificationsProcessedData RetrieveForNotificationsProcessedData[string]) ([]NotificationRecipient, error) {
 	teacher := retrieveForNotificationsProcessedData.Teacher
 	students := retrieveForNotificationsProcessedData.Students
 
-	err := checkTeacherStudentsExist(teacher, students)
+	err := checkTeacherStudentsExist(ctx, teacher, students)
 	if err != nil { return nil, err }
 
 	var registeredStudents []string
-	err = DB.QueryRow(context.Background(), `
+	err = DB.QueryRow(ctx, `
 		SELECT array_agg(DISTINCT student) AS students
 		FROM teacher_student_relationship
 		WHERE teacher = $1
@@ -141,19 +396,145 @@ func RetrieveForNotifications(retrieveForNotificationsProcessedData RetrieveForN
 		return nil, err
 	}
 
-	candidateRecipients := append(students, registeredStudents...)
-	
-	recipients := []string{}
-	for _, candidate := range candidateRecipients {
-		suspended, err := checkStudentSuspended(candidate)
+	explainedByEmail := map[string]*NotificationRecipient{}
+	explain := func(student string) (*NotificationRecipient, error) {
+		explained, seen := explainedByEmail[student]
+		if !seen {
+			explained = &NotificationRecipient{Email: student}
+			explainedByEmail[student] = explained
+		}
+
+		suspended, err := checkStudentSuspended(ctx, student)
+		explained.Suspended = suspended
+		return explained, err
+	}
+
+	for _, student := range students {
+		explained, err := explain(student)
+		if err != nil { return nil, err }
+		explained.Mentioned = true
+	}
+	for _, student := range registeredStudents {
+		explained, err := explain(student)
 		if err != nil { return nil, err }
+		explained.Registered = true
+	}
 
-		if !suspended {
-			recipients = append(recipients, candidate)
-		}
+	explained := []NotificationRecipient{}
+	for _, student := range explainedByEmail {
+		explained = append(explained, *student)
 	}
-	recipients = removeDuplicateStr(recipients)
-	sort.Strings(recipients)
+	sort.Slice(explained, func(i, j int) bool { return explained[i].Email < explained[j].Email })
 
-	return recipients, nil
-}
\ No newline at end of file
+	return explained, nil
+}
+
+func AddTeacher(ctx context.Context, teacher string) (err error) {
+	ctx, finishOperation := startOperation(ctx, "add_teacher")
+	defer func() { finishOperation(err) }()
+
+	var email string
+	err = DB.QueryRow(ctx, "INSERT INTO teacher(email) VALUES ($1) RETURNING email", teacher).Scan(&email)
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolationCode {
+		return fmt.Errorf(CustomErrors["teacherAlreadyExists"].Message, errors.New("teacherAlreadyExists"), teacher)
+	} else if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func AddStudent(ctx context.Context, student string) (err error) {
+	ctx, finishOperation := startOperation(ctx, "add_student")
+	defer func() { finishOperation(err) }()
+
+	var email string
+	err = DB.QueryRow(ctx, "INSERT INTO student(email, suspended) VALUES ($1, false) RETURNING email", student).Scan(&email)
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolationCode {
+		return fmt.Errorf(CustomErrors["studentAlreadyExists"].Message, errors.New("studentAlreadyExists"), student)
+	} else if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+type RosterEntry struct {
+	Teacher string
+	Student string
+}
+
+type RosterImportSummary struct {
+	TeachersAdded      int
+	StudentsAdded      int
+	RegistrationsAdded int
+}
+
+func AddTeachersIfNotExist(ctx context.Context, teachers []string) (int, error) {
+	return addTeachersIfNotExist(ctx, DB, teachers)
+}
+
+func addTeachersIfNotExist(ctx context.Context, db rowQuerier, teachers []string) (int, error) {
+	teachersAdded := 0
+	for _, teacher := range teachers {
+		teacherAdded, err := insertIfNotExists(ctx, db, "INSERT INTO teacher(email) VALUES ($1) ON CONFLICT (email) DO NOTHING RETURNING email", teacher)
+		if err != nil { return teachersAdded, err }
+		if teacherAdded { teachersAdded++ }
+	}
+
+	return teachersAdded, nil
+}
+
+func AddStudentsIfNotExist(ctx context.Context, students []string) (int, error) {
+	return addStudentsIfNotExist(ctx, DB, students)
+}
+
+func addStudentsIfNotExist(ctx context.Context, db rowQuerier, students []string) (int, error) {
+	studentsAdded := 0
+	for _, student := range students {
+		studentAdded, err := insertIfNotExists(ctx, db, "INSERT INTO student(email, suspended) VALUES ($1, false) ON CONFLICT (email) DO NOTHING RETURNING email", student)
+		if err != nil { return studentsAdded, err }
+		if studentAdded { studentsAdded++ }
+	}
+
+	return studentsAdded, nil
+}
+
+// ImportRoster adds any teachers, students and registrations in the roster that do not exist yet.
+// Existing rows are left untouched, so importing the same roster twice is safe. The roster is imported in
+// a single transaction, so nothing is added if any of it fails
+func ImportRoster(ctx context.Context, roster []RosterEntry) (_ RosterImportSummary, err error) {
+	ctx, finishOperation := startOperation(ctx, "import_roster")
+	defer func() { finishOperation(err) }()
+
+	teachers := []string{}
+	students := []string{}
+	for _, entry := range roster {
+		teachers = append(teachers, entry.Teacher)
+		students = append(students, entry.Student)
+	}
+
+	summary := RosterImportSummary{}
+	err = pgx.BeginFunc(ctx, DB, func(tx pgx.Tx) error {
+		var err error
+		summary.TeachersAdded, err = addTeachersIfNotExist(ctx, tx, removeDuplicateStr(teachers))
+		if err != nil { return err }
+
+		summary.StudentsAdded, err = addStudentsIfNotExist(ctx, tx, removeDuplicateStr(students))
+		if err != nil { return err }
+
+		for _, entry := range roster {
+			registrationAdded, err := insertIfNotExists(ctx, tx, "INSERT INTO teacher_student_relationship(teacher, student) VALUES ($1, $2) ON CONFLICT (teacher, student) DO NOTHING RETURNING student", entry.Teacher, entry.Student)
+			if err != nil { return err }
+			if registrationAdded { summary.RegistrationsAdded++ }
+		}
+		return nil
+	})
+	if err != nil { return RosterImportSummary{}, err }
+
+	return summary, nil
+}
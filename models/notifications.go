@@ -0,0 +1,172 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Statuses of a notification. Notifications that are not scheduled are recorded as sent straight away
+const (
+	NotificationPending   = "pending"
+	NotificationSent      = "sent"
+	NotificationCancelled = "cancelled"
+	NotificationFailed    = "failed"
+)
+
+// ScheduledNotification is a notification to be sent at SendAt. Its recipients are resolved then, so that
+// suspensions and registrations in the meantime are respected
+type ScheduledNotification struct {
+	ID           int64     `json:"id"`
+	Teacher      string    `json:"teacher"`
+	Notification string    `json:"notification"`
+	SendAt       time.Time `json:"sendAt"`
+	Status       string    `json:"status"`
+	// The students @mentioned, who will receive it unless they are suspended by then
+	Mentions []string `json:"mentions"`
+}
+
+type ScheduledNotificationFilter struct {
+	// Empty for every teacher
+	Teacher string
+	Page
+}
+
+const scheduledNotificationColumns = "id, teacher, notification, send_at, status, mentions"
+
+func scanScheduledNotification(row pgx.Row) (ScheduledNotification, error) {
+	var notification ScheduledNotification
+	err := row.Scan(&notification.ID, &notification.Teacher, &notification.Notification, &notification.SendAt, &notification.Status, &notification.Mentions)
+	notification.SendAt = notification.SendAt.UTC()
+	return notification, err
+}
+
+// ScheduleNotification records a notification to be sent at sendAt. The teacher and the @mentioned students
+// must exist now, but who receives it is only decided when it is sent
+func ScheduleNotification(ctx context.Context, retrieveForNotificationsProcessedData RetrieveForNotificationsProcessedData[string], sendAt time.Time) (_ ScheduledNotification, err error) {
+	ctx, finishOperation := startOperation(ctx, "schedule_notification")
+	defer func() { finishOperation(err) }()
+
+	err = checkTeacherStudentsExist(ctx, retrieveForNotificationsProcessedData.Teacher, retrieveForNotificationsProcessedData.Students)
+	if err != nil { return ScheduledNotification{}, err }
+
+	return scanScheduledNotification(DB.QueryRow(ctx, `
+		INSERT INTO notification(teacher, notification, mentions, send_at, status, sent_at)
+		VALUES ($1, $2, $3, $4, 'pending', NULL)
+		RETURNING `+scheduledNotificationColumns,
+		retrieveForNotificationsProcessedData.Teacher, retrieveForNotificationsProcessedData.Notification, retrieveForNotificationsProcessedData.Students, sendAt))
+}
+
+// ListScheduledNotifications returns the notifications still waiting to be sent, the earliest first
+func ListScheduledNotifications(ctx context.Context, filter ScheduledNotificationFilter) (_ []ScheduledNotification, hasMore bool, err error) {
+	ctx, finishOperation := startOperation(ctx, "list_scheduled_notifications")
+	defer func() { finishOperation(err) }()
+
+	conditions := []string{"status = 'pending'"}
+	args := []any{}
+	if filter.Teacher != "" {
+		args = append(args, filter.Teacher)
+		conditions = append(conditions, fmt.Sprintf("teacher = $%d", len(args)))
+	}
+	args = append(args, filter.Limit+1, filter.Offset)
+
+	rows, err := DB.Query(ctx, fmt.Sprintf(`
+		SELECT %s
+		FROM notification
+		WHERE %s
+		ORDER BY send_at, id
+		LIMIT $%d OFFSET $%d
+	`, scheduledNotificationColumns, strings.Join(conditions, " AND "), len(args)-1, len(args)), args...)
+	if err != nil { return nil, false, err }
+
+	notifications, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (ScheduledNotification, error) {
+		return scanScheduledNotification(row)
+	})
+	if err != nil { return nil, false, err }
+
+	notifications, hasMore = trimPage(notifications, filter.Page)
+	return notifications, hasMore, nil
+}
+
+// GetScheduledNotification returns a notification that is still waiting to be sent
+func GetScheduledNotification(ctx context.Context, id int64) (_ ScheduledNotification, err error) {
+	ctx, finishOperation := startOperation(ctx, "get_scheduled_notification")
+	defer func() { finishOperation(err) }()
+
+	notification, err := scanScheduledNotification(DB.QueryRow(ctx,
+		"SELECT "+scheduledNotificationColumns+" FROM notification WHERE id = $1 AND status = 'pending'", id))
+	if err == pgx.ErrNoRows {
+		return ScheduledNotification{}, fmt.Errorf(CustomErrors["nonExistentScheduledNotification"].Message, errors.New("nonExistentScheduledNotification"), id)
+	}
+	return notification, err
+}
+
+// CancelScheduledNotification stops a notification from being sent, unless it already has been
+func CancelScheduledNotification(ctx context.Context, id int64) (_ ScheduledNotification, err error) {
+	ctx, finishOperation := startOperation(ctx, "cancel_scheduled_notification")
+	defer func() { finishOperation(err) }()
+
+	notification, err := scanScheduledNotification(DB.QueryRow(ctx,
+		"UPDATE notification SET status = 'cancelled' WHERE id = $1 AND status = 'pending' RETURNING "+scheduledNotificationColumns, id))
+	if err == pgx.ErrNoRows {
+		return ScheduledNotification{}, fmt.Errorf(CustomErrors["nonExistentScheduledNotification"].Message, errors.New("nonExistentScheduledNotification"), id)
+	}
+	return notification, err
+}
+
+// GetDueNotifications returns up to limit pending notifications whose send time has come, the earliest first
+func GetDueNotifications(ctx context.Context, now time.Time, limit int) (_ []ScheduledNotification, err error) {
+	ctx, finishOperation := startOperation(ctx, "due_notifications")
+	defer func() { finishOperation(err) }()
+
+	rows, err := DB.Query(ctx, `
+		SELECT `+scheduledNotificationColumns+`
+		FROM notification
+		WHERE status = 'pending' AND send_at <= $1
+		ORDER BY send_at, id
+		LIMIT $2
+	`, now, limit)
+	if err != nil { return nil, err }
+
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (ScheduledNotification, error) {
+		return scanScheduledNotification(row)
+	})
+}
+
+// SendScheduledNotification resolves the recipients of a due notification and records it as sent, returning the
+// status it was recorded with. The status is empty when the notification was cancelled, or sent by another
+// instance, in the meantime. Notifications whose teacher or @mentioned students no longer exist are recorded as
+// failed, along with the reason. Other errors leave the notification pending, to be retried
+func SendScheduledNotification(ctx context.Context, notification ScheduledNotification) (status string, recipients []string, err error) {
+	ctx, finishOperation := startOperation(ctx, "send_scheduled_notification")
+	defer func() { finishOperation(err) }()
+
+	status = NotificationSent
+	recipients, resolveErr := getNotificationRecipients(ctx, RetrieveForNotificationsProcessedData[string]{
+		Teacher: notification.Teacher,
+		Students: notification.Mentions,
+		Notification: notification.Notification,
+	})
+	if isCustomError(resolveErr) {
+		status = NotificationFailed
+		recipients = []string{}
+	} else if resolveErr != nil {
+		return "", nil, resolveErr
+	}
+
+	// Only pending notifications are updated, so that each is sent once even if several instances are scheduling
+	var id int64
+	err = DB.QueryRow(ctx, "UPDATE notification SET status = $2, recipients = $3, sent_at = now() WHERE id = $1 AND status = 'pending' RETURNING id",
+		notification.ID, status, recipients).Scan(&id)
+	if err == pgx.ErrNoRows {
+		return "", nil, nil
+	} else if err != nil {
+		return "", nil, err
+	}
+
+	return status, recipients, resolveErr
+}
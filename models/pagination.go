@@ -0,0 +1,15 @@
+package models
+
+// Page selects part of a listing. Listings fetch one row more than Limit to tell whether there is another page
+type Page struct {
+	Limit  int
+	Offset int
+}
+
+// trimPage drops the extra row fetched for page and reports whether there was one
+func trimPage[T any](items []T, page Page) ([]T, bool) {
+	if len(items) > page.Limit {
+		return items[:page.Limit], true
+	}
+	return items, false
+}
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"context"
+	"time"
+)
+
+// TakeRateLimitToken takes a token from the bucket named key, refilling it at rate tokens per second
+// up to burst tokens. When the bucket is empty no token is taken and retryAfter says when one will be available.
+// Concurrent calls are serialized by the row lock taken by the upsert
+func TakeRateLimitToken(ctx context.Context, key string, rate float64, burst int) (allowed bool, retryAfter time.Duration, err error) {
+	ctx, finishOperation := startOperation(ctx, "take_rate_limit_token")
+	defer func() { finishOperation(err) }()
+
+	var tokens float64
+	err = DB.QueryRow(ctx, `
+		INSERT INTO rate_limit_bucket AS bucket (key, tokens, allowed, updated_at)
+		VALUES ($1, $3::float8 - 1, true, now())
+		ON CONFLICT (key) DO UPDATE SET
+			tokens = LEAST($3::float8, bucket.tokens + EXTRACT(EPOCH FROM now() - bucket.updated_at)::float8 * $2::float8)
+				- CASE WHEN LEAST($3::float8, bucket.tokens + EXTRACT(EPOCH FROM now() - bucket.updated_at)::float8 * $2::float8) >= 1 THEN 1 ELSE 0 END,
+			allowed = LEAST($3::float8, bucket.tokens + EXTRACT(EPOCH FROM now() - bucket.updated_at)::float8 * $2::float8) >= 1,
+			updated_at = now()
+		RETURNING allowed, tokens
+	`, key, rate, burst).Scan(&allowed, &tokens)
+	if err != nil { return false, 0, err }
+
+	if allowed { return true, 0, nil }
+	return false, time.Duration((1 - tokens) / rate * float64(time.Second)), nil
+}
+
+// DeleteIdleRateLimitBuckets deletes buckets not used for longer than idleFor. A bucket idle for its refill
+// window is full again, so deleting it is harmless: it is recreated full on its next request
+func DeleteIdleRateLimitBuckets(ctx context.Context, idleFor time.Duration) (deleted int, err error) {
+	ctx, finishOperation := startOperation(ctx, "delete_idle_rate_limit_buckets")
+	defer func() { finishOperation(err) }()
+
+	err = DB.QueryRow(ctx, `
+		WITH deleted AS (
+			DELETE FROM rate_limit_bucket WHERE updated_at < now() - $1::float8 * interval '1 second' RETURNING 1
+		)
+		SELECT count(*) FROM deleted
+	`, idleFor.Seconds()).Scan(&deleted)
+	return deleted, err
+}
@@ -0,0 +1,89 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Aggregates for principals, computed in SQL so that they stay cheap however many rows they cover
+
+type TeacherReportRow struct {
+	Teacher           string `json:"teacher"`
+	Students          int    `json:"students"`
+	SuspendedStudents int    `json:"suspendedStudents"`
+}
+
+type SuspensionReport struct {
+	Students          int `json:"students"`
+	SuspendedStudents int `json:"suspendedStudents"`
+}
+
+type NotificationReportRow struct {
+	Teacher string `json:"teacher"`
+	// Monday 00:00 UTC of the week the notifications were sent in
+	Week              time.Time `json:"week"`
+	Notifications     int       `json:"notifications"`
+	AverageRecipients float64   `json:"averageRecipients"`
+}
+
+// GetTeacherReport returns how many students, and how many suspended students, each teacher has
+func GetTeacherReport(ctx context.Context) (_ []TeacherReportRow, err error) {
+	ctx, finishOperation := startOperation(ctx, "teacher_report")
+	defer func() { finishOperation(err) }()
+
+	rows, err := DB.Query(ctx, `
+		SELECT teacher.email, COUNT(student.email), COUNT(student.email) FILTER (WHERE student.suspended)
+		FROM teacher
+		LEFT JOIN teacher_student_relationship ON teacher_student_relationship.teacher = teacher.email
+		LEFT JOIN student ON student.email = teacher_student_relationship.student
+		GROUP BY teacher.email
+		ORDER BY teacher.email
+	`)
+	if err != nil { return nil, err }
+
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (TeacherReportRow, error) {
+		var reportRow TeacherReportRow
+		err := row.Scan(&reportRow.Teacher, &reportRow.Students, &reportRow.SuspendedStudents)
+		return reportRow, err
+	})
+}
+
+// GetSuspensionReport returns how many students there are, and how many of them are suspended
+func GetSuspensionReport(ctx context.Context) (_ SuspensionReport, err error) {
+	ctx, finishOperation := startOperation(ctx, "suspension_report")
+	defer func() { finishOperation(err) }()
+
+	var report SuspensionReport
+	err = DB.QueryRow(ctx, "SELECT COUNT(*), COUNT(*) FILTER (WHERE suspended) FROM student").Scan(&report.Students, &report.SuspendedStudents)
+	return report, err
+}
+
+// GetNotificationReport returns how many notifications each teacher sent per week, and how many students they
+// reached on average, for the notifications sent from since (inclusive) to until (exclusive). Zero times are unbounded
+func GetNotificationReport(ctx context.Context, since time.Time, until time.Time) (_ []NotificationReportRow, err error) {
+	ctx, finishOperation := startOperation(ctx, "notification_report")
+	defer func() { finishOperation(err) }()
+
+	rows, err := DB.Query(ctx, `
+		SELECT teacher, date_trunc('week', sent_at AT TIME ZONE 'UTC') AT TIME ZONE 'UTC' AS week, COUNT(*), AVG(cardinality(recipients))::float8
+		FROM notification
+		WHERE status = 'sent' AND ($1::timestamptz IS NULL OR sent_at >= $1) AND ($2::timestamptz IS NULL OR sent_at < $2)
+		GROUP BY teacher, week
+		ORDER BY week DESC, teacher
+	`, nullableTime(since), nullableTime(until))
+	if err != nil { return nil, err }
+
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (NotificationReportRow, error) {
+		var reportRow NotificationReportRow
+		err := row.Scan(&reportRow.Teacher, &reportRow.Week, &reportRow.Notifications, &reportRow.AverageRecipients)
+		reportRow.Week = reportRow.Week.UTC()
+		return reportRow, err
+	})
+}
+
+func nullableTime(value time.Time) *time.Time {
+	if value.IsZero() { return nil }
+	return &value
+}
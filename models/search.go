@@ -0,0 +1,91 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// StudentSearchFilter narrows SearchStudents down. Zero values match every student
+type StudentSearchFilter struct {
+	// Matches emails that start with, or are similar to, Query
+	Query string
+	// Matches only suspended (or only unsuspended) students when set
+	Suspended *bool
+	// Matches the students registered to Teacher
+	Teacher string
+	Page
+}
+
+type StudentSearchResult struct {
+	ID        string `json:"id"`
+	Email     string `json:"email"`
+	Suspended bool   `json:"suspended"`
+}
+
+// likeEscaper makes a string match itself literally in a LIKE pattern
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// SearchStudents returns the page of students matching filter, and whether there are more. Prefix matches come
+// first, then the most similar emails (pg_trgm). Without a query, students are in alphabetical order
+func SearchStudents(ctx context.Context, filter StudentSearchFilter) (_ []StudentSearchResult, hasMore bool, err error) {
+	ctx, finishOperation := startOperation(ctx, "search_students")
+	defer func() { finishOperation(err) }()
+
+	if filter.Teacher != "" {
+		teacherExists, err := checkTeacherExists(ctx, filter.Teacher)
+		if err != nil { return nil, false, err }
+		if !teacherExists {
+			return nil, false, fmt.Errorf(CustomErrors["nonExistentTeacher"].Message, errors.New("nonExistentTeacher"), filter.Teacher)
+		}
+	}
+
+	conditions := []string{}
+	args := []any{}
+	addCondition := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	orderBy := "email"
+	if filter.Query != "" {
+		query := strings.ToLower(filter.Query)
+		args = append(args, likeEscaper.Replace(query)+"%", query)
+		prefix, similar := len(args)-1, len(args)
+
+		conditions = append(conditions, fmt.Sprintf("(lower(email::text) LIKE $%d OR lower(email::text) %% $%d)", prefix, similar))
+		orderBy = fmt.Sprintf("lower(email::text) LIKE $%d DESC, similarity(lower(email::text), $%d) DESC, email", prefix, similar)
+	}
+	if filter.Suspended != nil { addCondition("COALESCE(suspended, false) = $%d", *filter.Suspended) }
+	if filter.Teacher != "" { addCondition("EXISTS (SELECT 1 FROM teacher_student_relationship WHERE student = student.email AND teacher = $%d)", filter.Teacher) }
+
+	where := ""
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+	args = append(args, filter.Limit+1, filter.Offset)
+
+	rows, err := DB.Query(ctx, fmt.Sprintf(`
+		SELECT id, email, COALESCE(suspended, false)
+		FROM student
+		%s
+		ORDER BY %s
+		LIMIT $%d OFFSET $%d
+	`, where, orderBy, len(args)-1, len(args)), args...)
+
+	if err != nil { return nil, false, err }
+	defer rows.Close()
+
+	students := []StudentSearchResult{}
+	for rows.Next() {
+		var student StudentSearchResult
+		if err := rows.Scan(&student.ID, &student.Email, &student.Suspended); err != nil { return nil, false, err }
+
+		students = append(students, student)
+	}
+	if err := rows.Err(); err != nil { return nil, false, err }
+
+	students, hasMore = trimPage(students, filter.Page)
+	return students, hasMore, nil
+}
@@ -0,0 +1,623 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.31.0
+// 	protoc        (unknown)
+// source: onecvpb/onecv.proto
+
+package onecvpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Mirrors models.StudentRegistrationData
+type RegisterStudentsRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Teacher  string   `protobuf:"bytes,1,opt,name=teacher,proto3" json:"teacher,omitempty"`
+	Students []string `protobuf:"bytes,2,rep,name=students,proto3" json:"students,omitempty"`
+}
+
+func (x *RegisterStudentsRequest) Reset() {
+	*x = RegisterStudentsRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_onecvpb_onecv_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RegisterStudentsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RegisterStudentsRequest) ProtoMessage() {}
+
+func (x *RegisterStudentsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_onecvpb_onecv_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RegisterStudentsRequest.ProtoReflect.Descriptor instead.
+func (*RegisterStudentsRequest) Descriptor() ([]byte, []int) {
+	return file_onecvpb_onecv_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *RegisterStudentsRequest) GetTeacher() string {
+	if x != nil {
+		return x.Teacher
+	}
+	return ""
+}
+
+func (x *RegisterStudentsRequest) GetStudents() []string {
+	if x != nil {
+		return x.Students
+	}
+	return nil
+}
+
+type RegisterStudentsResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *RegisterStudentsResponse) Reset() {
+	*x = RegisterStudentsResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_onecvpb_onecv_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RegisterStudentsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RegisterStudentsResponse) ProtoMessage() {}
+
+func (x *RegisterStudentsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_onecvpb_onecv_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RegisterStudentsResponse.ProtoReflect.Descriptor instead.
+func (*RegisterStudentsResponse) Descriptor() ([]byte, []int) {
+	return file_onecvpb_onecv_proto_rawDescGZIP(), []int{1}
+}
+
+type GetCommonStudentsRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Teachers []string `protobuf:"bytes,1,rep,name=teachers,proto3" json:"teachers,omitempty"`
+}
+
+func (x *GetCommonStudentsRequest) Reset() {
+	*x = GetCommonStudentsRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_onecvpb_onecv_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetCommonStudentsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetCommonStudentsRequest) ProtoMessage() {}
+
+func (x *GetCommonStudentsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_onecvpb_onecv_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetCommonStudentsRequest.ProtoReflect.Descriptor instead.
+func (*GetCommonStudentsRequest) Descriptor() ([]byte, []int) {
+	return file_onecvpb_onecv_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *GetCommonStudentsRequest) GetTeachers() []string {
+	if x != nil {
+		return x.Teachers
+	}
+	return nil
+}
+
+type GetCommonStudentsResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Students []string `protobuf:"bytes,1,rep,name=students,proto3" json:"students,omitempty"`
+}
+
+func (x *GetCommonStudentsResponse) Reset() {
+	*x = GetCommonStudentsResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_onecvpb_onecv_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetCommonStudentsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetCommonStudentsResponse) ProtoMessage() {}
+
+func (x *GetCommonStudentsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_onecvpb_onecv_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetCommonStudentsResponse.ProtoReflect.Descriptor instead.
+func (*GetCommonStudentsResponse) Descriptor() ([]byte, []int) {
+	return file_onecvpb_onecv_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *GetCommonStudentsResponse) GetStudents() []string {
+	if x != nil {
+		return x.Students
+	}
+	return nil
+}
+
+// Mirrors models.StudentSuspensionData
+type SuspendStudentRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Student string `protobuf:"bytes,1,opt,name=student,proto3" json:"student,omitempty"`
+}
+
+func (x *SuspendStudentRequest) Reset() {
+	*x = SuspendStudentRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_onecvpb_onecv_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SuspendStudentRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SuspendStudentRequest) ProtoMessage() {}
+
+func (x *SuspendStudentRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_onecvpb_onecv_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SuspendStudentRequest.ProtoReflect.Descriptor instead.
+func (*SuspendStudentRequest) Descriptor() ([]byte, []int) {
+	return file_onecvpb_onecv_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *SuspendStudentRequest) GetStudent() string {
+	if x != nil {
+		return x.Student
+	}
+	return ""
+}
+
+type SuspendStudentResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *SuspendStudentResponse) Reset() {
+	*x = SuspendStudentResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_onecvpb_onecv_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SuspendStudentResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SuspendStudentResponse) ProtoMessage() {}
+
+func (x *SuspendStudentResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_onecvpb_onecv_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SuspendStudentResponse.ProtoReflect.Descriptor instead.
+func (*SuspendStudentResponse) Descriptor() ([]byte, []int) {
+	return file_onecvpb_onecv_proto_rawDescGZIP(), []int{5}
+}
+
+// Mirrors models.RetrieveForNotificationsData
+type RetrieveForNotificationsRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Teacher      string `protobuf:"bytes,1,opt,name=teacher,proto3" json:"teacher,omitempty"`
+	Notification string `protobuf:"bytes,2,opt,name=notification,proto3" json:"notification,omitempty"`
+}
+
+func (x *RetrieveForNotificationsRequest) Reset() {
+	*x = RetrieveForNotificationsRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_onecvpb_onecv_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RetrieveForNotificationsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RetrieveForNotificationsRequest) ProtoMessage() {}
+
+func (x *RetrieveForNotificationsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_onecvpb_onecv_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RetrieveForNotificationsRequest.ProtoReflect.Descriptor instead.
+func (*RetrieveForNotificationsRequest) Descriptor() ([]byte, []int) {
+	return file_onecvpb_onecv_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *RetrieveForNotificationsRequest) GetTeacher() string {
+	if x != nil {
+		return x.Teacher
+	}
+	return ""
+}
+
+func (x *RetrieveForNotificationsRequest) GetNotification() string {
+	if x != nil {
+		return x.Notification
+	}
+	return ""
+}
+
+type RetrieveForNotificationsResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Recipients []string `protobuf:"bytes,1,rep,name=recipients,proto3" json:"recipients,omitempty"`
+}
+
+func (x *RetrieveForNotificationsResponse) Reset() {
+	*x = RetrieveForNotificationsResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_onecvpb_onecv_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RetrieveForNotificationsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RetrieveForNotificationsResponse) ProtoMessage() {}
+
+func (x *RetrieveForNotificationsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_onecvpb_onecv_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RetrieveForNotificationsResponse.ProtoReflect.Descriptor instead.
+func (*RetrieveForNotificationsResponse) Descriptor() ([]byte, []int) {
+	return file_onecvpb_onecv_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *RetrieveForNotificationsResponse) GetRecipients() []string {
+	if x != nil {
+		return x.Recipients
+	}
+	return nil
+}
+
+var File_onecvpb_onecv_proto protoreflect.FileDescriptor
+
+var file_onecvpb_onecv_proto_rawDesc = []byte{
+	0x0a, 0x13, 0x6f, 0x6e, 0x65, 0x63, 0x76, 0x70, 0x62, 0x2f, 0x6f, 0x6e, 0x65, 0x63, 0x76, 0x2e,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x08, 0x6f, 0x6e, 0x65, 0x63, 0x76, 0x2e, 0x76, 0x31, 0x22,
+	0x4f, 0x0a, 0x17, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x65, 0x72, 0x53, 0x74, 0x75, 0x64, 0x65,
+	0x6e, 0x74, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x18, 0x0a, 0x07, 0x74, 0x65,
+	0x61, 0x63, 0x68, 0x65, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x74, 0x65, 0x61,
+	0x63, 0x68, 0x65, 0x72, 0x12, 0x1a, 0x0a, 0x08, 0x73, 0x74, 0x75, 0x64, 0x65, 0x6e, 0x74, 0x73,
+	0x18, 0x02, 0x20, 0x03, 0x28, 0x09, 0x52, 0x08, 0x73, 0x74, 0x75, 0x64, 0x65, 0x6e, 0x74, 0x73,
+	0x22, 0x1a, 0x0a, 0x18, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x65, 0x72, 0x53, 0x74, 0x75, 0x64,
+	0x65, 0x6e, 0x74, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x36, 0x0a, 0x18,
+	0x47, 0x65, 0x74, 0x43, 0x6f, 0x6d, 0x6d, 0x6f, 0x6e, 0x53, 0x74, 0x75, 0x64, 0x65, 0x6e, 0x74,
+	0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1a, 0x0a, 0x08, 0x74, 0x65, 0x61, 0x63,
+	0x68, 0x65, 0x72, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x09, 0x52, 0x08, 0x74, 0x65, 0x61, 0x63,
+	0x68, 0x65, 0x72, 0x73, 0x22, 0x37, 0x0a, 0x19, 0x47, 0x65, 0x74, 0x43, 0x6f, 0x6d, 0x6d, 0x6f,
+	0x6e, 0x53, 0x74, 0x75, 0x64, 0x65, 0x6e, 0x74, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
+	0x65, 0x12, 0x1a, 0x0a, 0x08, 0x73, 0x74, 0x75, 0x64, 0x65, 0x6e, 0x74, 0x73, 0x18, 0x01, 0x20,
+	0x03, 0x28, 0x09, 0x52, 0x08, 0x73, 0x74, 0x75, 0x64, 0x65, 0x6e, 0x74, 0x73, 0x22, 0x31, 0x0a,
+	0x15, 0x53, 0x75, 0x73, 0x70, 0x65, 0x6e, 0x64, 0x53, 0x74, 0x75, 0x64, 0x65, 0x6e, 0x74, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x18, 0x0a, 0x07, 0x73, 0x74, 0x75, 0x64, 0x65, 0x6e,
+	0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x73, 0x74, 0x75, 0x64, 0x65, 0x6e, 0x74,
+	0x22, 0x18, 0x0a, 0x16, 0x53, 0x75, 0x73, 0x70, 0x65, 0x6e, 0x64, 0x53, 0x74, 0x75, 0x64, 0x65,
+	0x6e, 0x74, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x5f, 0x0a, 0x1f, 0x52, 0x65,
+	0x74, 0x72, 0x69, 0x65, 0x76, 0x65, 0x46, 0x6f, 0x72, 0x4e, 0x6f, 0x74, 0x69, 0x66, 0x69, 0x63,
+	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x18, 0x0a,
+	0x07, 0x74, 0x65, 0x61, 0x63, 0x68, 0x65, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07,
+	0x74, 0x65, 0x61, 0x63, 0x68, 0x65, 0x72, 0x12, 0x22, 0x0a, 0x0c, 0x6e, 0x6f, 0x74, 0x69, 0x66,
+	0x69, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x6e,
+	0x6f, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x22, 0x42, 0x0a, 0x20, 0x52,
+	0x65, 0x74, 0x72, 0x69, 0x65, 0x76, 0x65, 0x46, 0x6f, 0x72, 0x4e, 0x6f, 0x74, 0x69, 0x66, 0x69,
+	0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12,
+	0x1e, 0x0a, 0x0a, 0x72, 0x65, 0x63, 0x69, 0x70, 0x69, 0x65, 0x6e, 0x74, 0x73, 0x18, 0x01, 0x20,
+	0x03, 0x28, 0x09, 0x52, 0x0a, 0x72, 0x65, 0x63, 0x69, 0x70, 0x69, 0x65, 0x6e, 0x74, 0x73, 0x32,
+	0x88, 0x03, 0x0a, 0x05, 0x4f, 0x6e, 0x65, 0x43, 0x56, 0x12, 0x59, 0x0a, 0x10, 0x52, 0x65, 0x67,
+	0x69, 0x73, 0x74, 0x65, 0x72, 0x53, 0x74, 0x75, 0x64, 0x65, 0x6e, 0x74, 0x73, 0x12, 0x21, 0x2e,
+	0x6f, 0x6e, 0x65, 0x63, 0x76, 0x2e, 0x76, 0x31, 0x2e, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x65,
+	0x72, 0x53, 0x74, 0x75, 0x64, 0x65, 0x6e, 0x74, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x1a, 0x22, 0x2e, 0x6f, 0x6e, 0x65, 0x63, 0x76, 0x2e, 0x76, 0x31, 0x2e, 0x52, 0x65, 0x67, 0x69,
+	0x73, 0x74, 0x65, 0x72, 0x53, 0x74, 0x75, 0x64, 0x65, 0x6e, 0x74, 0x73, 0x52, 0x65, 0x73, 0x70,
+	0x6f, 0x6e, 0x73, 0x65, 0x12, 0x5c, 0x0a, 0x11, 0x47, 0x65, 0x74, 0x43, 0x6f, 0x6d, 0x6d, 0x6f,
+	0x6e, 0x53, 0x74, 0x75, 0x64, 0x65, 0x6e, 0x74, 0x73, 0x12, 0x22, 0x2e, 0x6f, 0x6e, 0x65, 0x63,
+	0x76, 0x2e, 0x76, 0x31, 0x2e, 0x47, 0x65, 0x74, 0x43, 0x6f, 0x6d, 0x6d, 0x6f, 0x6e, 0x53, 0x74,
+	0x75, 0x64, 0x65, 0x6e, 0x74, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x23, 0x2e,
+	0x6f, 0x6e, 0x65, 0x63, 0x76, 0x2e, 0x76, 0x31, 0x2e, 0x47, 0x65, 0x74, 0x43, 0x6f, 0x6d, 0x6d,
+	0x6f, 0x6e, 0x53, 0x74, 0x75, 0x64, 0x65, 0x6e, 0x74, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e,
+	0x73, 0x65, 0x12, 0x53, 0x0a, 0x0e, 0x53, 0x75, 0x73, 0x70, 0x65, 0x6e, 0x64, 0x53, 0x74, 0x75,
+	0x64, 0x65, 0x6e, 0x74, 0x12, 0x1f, 0x2e, 0x6f, 0x6e, 0x65, 0x63, 0x76, 0x2e, 0x76, 0x31, 0x2e,
+	0x53, 0x75, 0x73, 0x70, 0x65, 0x6e, 0x64, 0x53, 0x74, 0x75, 0x64, 0x65, 0x6e, 0x74, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x20, 0x2e, 0x6f, 0x6e, 0x65, 0x63, 0x76, 0x2e, 0x76, 0x31,
+	0x2e, 0x53, 0x75, 0x73, 0x70, 0x65, 0x6e, 0x64, 0x53, 0x74, 0x75, 0x64, 0x65, 0x6e, 0x74, 0x52,
+	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x71, 0x0a, 0x18, 0x52, 0x65, 0x74, 0x72, 0x69,
+	0x65, 0x76, 0x65, 0x46, 0x6f, 0x72, 0x4e, 0x6f, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x69,
+	0x6f, 0x6e, 0x73, 0x12, 0x29, 0x2e, 0x6f, 0x6e, 0x65, 0x63, 0x76, 0x2e, 0x76, 0x31, 0x2e, 0x52,
+	0x65, 0x74, 0x72, 0x69, 0x65, 0x76, 0x65, 0x46, 0x6f, 0x72, 0x4e, 0x6f, 0x74, 0x69, 0x66, 0x69,
+	0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x2a,
+	0x2e, 0x6f, 0x6e, 0x65, 0x63, 0x76, 0x2e, 0x76, 0x31, 0x2e, 0x52, 0x65, 0x74, 0x72, 0x69, 0x65,
+	0x76, 0x65, 0x46, 0x6f, 0x72, 0x4e, 0x6f, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x69, 0x6f,
+	0x6e, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x42, 0x1a, 0x5a, 0x18, 0x6f, 0x6e,
+	0x65, 0x63, 0x76, 0x2d, 0x67, 0x6f, 0x2d, 0x62, 0x61, 0x63, 0x6b, 0x65, 0x6e, 0x64, 0x2f, 0x6f,
+	0x6e, 0x65, 0x63, 0x76, 0x70, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_onecvpb_onecv_proto_rawDescOnce sync.Once
+	file_onecvpb_onecv_proto_rawDescData = file_onecvpb_onecv_proto_rawDesc
+)
+
+func file_onecvpb_onecv_proto_rawDescGZIP() []byte {
+	file_onecvpb_onecv_proto_rawDescOnce.Do(func() {
+		file_onecvpb_onecv_proto_rawDescData = protoimpl.X.CompressGZIP(file_onecvpb_onecv_proto_rawDescData)
+	})
+	return file_onecvpb_onecv_proto_rawDescData
+}
+
+var file_onecvpb_onecv_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
+var file_onecvpb_onecv_proto_goTypes = []interface{}{
+	(*RegisterStudentsRequest)(nil),          // 0: onecv.v1.RegisterStudentsRequest
+	(*RegisterStudentsResponse)(nil),         // 1: onecv.v1.RegisterStudentsResponse
+	(*GetCommonStudentsRequest)(nil),         // 2: onecv.v1.GetCommonStudentsRequest
+	(*GetCommonStudentsResponse)(nil),        // 3: onecv.v1.GetCommonStudentsResponse
+	(*SuspendStudentRequest)(nil),            // 4: onecv.v1.SuspendStudentRequest
+	(*SuspendStudentResponse)(nil),           // 5: onecv.v1.SuspendStudentResponse
+	(*RetrieveForNotificationsRequest)(nil),  // 6: onecv.v1.RetrieveForNotificationsRequest
+	(*RetrieveForNotificationsResponse)(nil), // 7: onecv.v1.RetrieveForNotificationsResponse
+}
+var file_onecvpb_onecv_proto_depIdxs = []int32{
+	0, // 0: onecv.v1.OneCV.RegisterStudents:input_type -> onecv.v1.RegisterStudentsRequest
+	2, // 1: onecv.v1.OneCV.GetCommonStudents:input_type -> onecv.v1.GetCommonStudentsRequest
+	4, // 2: onecv.v1.OneCV.SuspendStudent:input_type -> onecv.v1.SuspendStudentRequest
+	6, // 3: onecv.v1.OneCV.RetrieveForNotifications:input_type -> onecv.v1.RetrieveForNotificationsRequest
+	1, // 4: onecv.v1.OneCV.RegisterStudents:output_type -> onecv.v1.RegisterStudentsResponse
+	3, // 5: onecv.v1.OneCV.GetCommonStudents:output_type -> onecv.v1.GetCommonStudentsResponse
+	5, // 6: onecv.v1.OneCV.SuspendStudent:output_type -> onecv.v1.SuspendStudentResponse
+	7, // 7: onecv.v1.OneCV.RetrieveForNotifications:output_type -> onecv.v1.RetrieveForNotificationsResponse
+	4, // [4:8] is the sub-list for method output_type
+	0, // [0:4] is the sub-list for method input_type
+	0, // [0:0] is the sub-list for extension type_name
+	0, // [0:0] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_onecvpb_onecv_proto_init() }
+func file_onecvpb_onecv_proto_init() {
+	if File_onecvpb_onecv_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_onecvpb_onecv_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RegisterStudentsRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_onecvpb_onecv_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RegisterStudentsResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_onecvpb_onecv_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetCommonStudentsRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_onecvpb_onecv_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetCommonStudentsResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_onecvpb_onecv_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SuspendStudentRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_onecvpb_onecv_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SuspendStudentResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_onecvpb_onecv_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RetrieveForNotificationsRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_onecvpb_onecv_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RetrieveForNotificationsResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_onecvpb_onecv_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   8,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_onecvpb_onecv_proto_goTypes,
+		DependencyIndexes: file_onecvpb_onecv_proto_depIdxs,
+		MessageInfos:      file_onecvpb_onecv_proto_msgTypes,
+	}.Build()
+	File_onecvpb_onecv_proto = out.File
+	file_onecvpb_onecv_proto_rawDesc = nil
+	file_onecvpb_onecv_proto_goTypes = nil
+	file_onecvpb_onecv_proto_depIdxs = nil
+}
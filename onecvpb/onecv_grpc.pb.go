@@ -0,0 +1,220 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             (unknown)
+// source: onecvpb/onecv.proto
+
+package onecvpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	OneCV_RegisterStudents_FullMethodName         = "/onecv.v1.OneCV/RegisterStudents"
+	OneCV_GetCommonStudents_FullMethodName        = "/onecv.v1.OneCV/GetCommonStudents"
+	OneCV_SuspendStudent_FullMethodName           = "/onecv.v1.OneCV/SuspendStudent"
+	OneCV_RetrieveForNotifications_FullMethodName = "/onecv.v1.OneCV/RetrieveForNotifications"
+)
+
+// OneCVClient is the client API for OneCV service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type OneCVClient interface {
+	RegisterStudents(ctx context.Context, in *RegisterStudentsRequest, opts ...grpc.CallOption) (*RegisterStudentsResponse, error)
+	GetCommonStudents(ctx context.Context, in *GetCommonStudentsRequest, opts ...grpc.CallOption) (*GetCommonStudentsResponse, error)
+	SuspendStudent(ctx context.Context, in *SuspendStudentRequest, opts ...grpc.CallOption) (*SuspendStudentResponse, error)
+	RetrieveForNotifications(ctx context.Context, in *RetrieveForNotificationsRequest, opts ...grpc.CallOption) (*RetrieveForNotificationsResponse, error)
+}
+
+type oneCVClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewOneCVClient(cc grpc.ClientConnInterface) OneCVClient {
+	return &oneCVClient{cc}
+}
+
+func (c *oneCVClient) RegisterStudents(ctx context.Context, in *RegisterStudentsRequest, opts ...grpc.CallOption) (*RegisterStudentsResponse, error) {
+	out := new(RegisterStudentsResponse)
+	err := c.cc.Invoke(ctx, OneCV_RegisterStudents_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *oneCVClient) GetCommonStudents(ctx context.Context, in *GetCommonStudentsRequest, opts ...grpc.CallOption) (*GetCommonStudentsResponse, error) {
+	out := new(GetCommonStudentsResponse)
+	err := c.cc.Invoke(ctx, OneCV_GetCommonStudents_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *oneCVClient) SuspendStudent(ctx context.Context, in *SuspendStudentRequest, opts ...grpc.CallOption) (*SuspendStudentResponse, error) {
+	out := new(SuspendStudentResponse)
+	err := c.cc.Invoke(ctx, OneCV_SuspendStudent_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *oneCVClient) RetrieveForNotifications(ctx context.Context, in *RetrieveForNotificationsRequest, opts ...grpc.CallOption) (*RetrieveForNotificationsResponse, error) {
+	out := new(RetrieveForNotificationsResponse)
+	err := c.cc.Invoke(ctx, OneCV_RetrieveForNotifications_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// OneCVServer is the server API for OneCV service.
+// All implementations must embed UnimplementedOneCVServer
+// for forward compatibility
+type OneCVServer interface {
+	RegisterStudents(context.Context, *RegisterStudentsRequest) (*RegisterStudentsResponse, error)
+	GetCommonStudents(context.Context, *GetCommonStudentsRequest) (*GetCommonStudentsResponse, error)
+	SuspendStudent(context.Context, *SuspendStudentRequest) (*SuspendStudentResponse, error)
+	RetrieveForNotifications(context.Context, *RetrieveForNotificationsRequest) (*RetrieveForNotificationsResponse, error)
+	mustEmbedUnimplementedOneCVServer()
+}
+
+// UnimplementedOneCVServer must be embedded to have forward compatible implementations.
+type UnimplementedOneCVServer struct {
+}
+
+func (UnimplementedOneCVServer) RegisterStudents(context.Context, *RegisterStudentsRequest) (*RegisterStudentsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RegisterStudents not implemented")
+}
+func (UnimplementedOneCVServer) GetCommonStudents(context.Context, *GetCommonStudentsRequest) (*GetCommonStudentsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetCommonStudents not implemented")
+}
+func (UnimplementedOneCVServer) SuspendStudent(context.Context, *SuspendStudentRequest) (*SuspendStudentResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SuspendStudent not implemented")
+}
+func (UnimplementedOneCVServer) RetrieveForNotifications(context.Context, *RetrieveForNotificationsRequest) (*RetrieveForNotificationsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RetrieveForNotifications not implemented")
+}
+func (UnimplementedOneCVServer) mustEmbedUnimplementedOneCVServer() {}
+
+// UnsafeOneCVServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to OneCVServer will
+// result in compilation errors.
+type UnsafeOneCVServer interface {
+	mustEmbedUnimplementedOneCVServer()
+}
+
+func RegisterOneCVServer(s grpc.ServiceRegistrar, srv OneCVServer) {
+	s.RegisterService(&OneCV_ServiceDesc, srv)
+}
+
+func _OneCV_RegisterStudents_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RegisterStudentsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OneCVServer).RegisterStudents(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: OneCV_RegisterStudents_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OneCVServer).RegisterStudents(ctx, req.(*RegisterStudentsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _OneCV_GetCommonStudents_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetCommonStudentsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OneCVServer).GetCommonStudents(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: OneCV_GetCommonStudents_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OneCVServer).GetCommonStudents(ctx, req.(*GetCommonStudentsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _OneCV_SuspendStudent_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SuspendStudentRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OneCVServer).SuspendStudent(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: OneCV_SuspendStudent_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OneCVServer).SuspendStudent(ctx, req.(*SuspendStudentRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _OneCV_RetrieveForNotifications_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RetrieveForNotificationsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(OneCVServer).RetrieveForNotifications(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: OneCV_RetrieveForNotifications_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(OneCVServer).RetrieveForNotifications(ctx, req.(*RetrieveForNotificationsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// OneCV_ServiceDesc is the grpc.ServiceDesc for OneCV service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var OneCV_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "onecv.v1.OneCV",
+	HandlerType: (*OneCVServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "RegisterStudents",
+			Handler:    _OneCV_RegisterStudents_Handler,
+		},
+		{
+			MethodName: "GetCommonStudents",
+			Handler:    _OneCV_GetCommonStudents_Handler,
+		},
+		{
+			MethodName: "SuspendStudent",
+			Handler:    _OneCV_SuspendStudent_Handler,
+		},
+		{
+			MethodName: "RetrieveForNotifications",
+			Handler:    _OneCV_RetrieveForNotifications_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "onecvpb/onecv.proto",
+}
@@ -0,0 +1,502 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"onecv-go-backend/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/graphql-go/graphql"
+)
+
+// routeSpec documents a route in router(). Request and response bodies are given as values of the
+// structs the handlers bind and return, and turned into schemas by reflection, so they cannot drift apart
+type routeSpec struct {
+	Summary string
+	Public bool
+	Parameters []parameterSpec
+	RequestBody any
+	Responses map[int]responseSpec
+}
+
+type parameterSpec struct {
+	Name string
+	// "path" or "query"
+	In string
+	Description string
+	Required bool
+	Example any
+	Schema map[string]any
+}
+
+type responseSpec struct {
+	Description string
+	// nil for responses without a body
+	Body any
+	// Defaults to application/json
+	ContentType string
+}
+
+var errorResponse = responseSpec{Description: "Error", Body: errorResponseBody{}}
+
+// The errors every authenticated, rate limited route can answer with, in addition to its own
+var commonErrorResponses = map[int]responseSpec{
+	http.StatusUnauthorized: {Description: "Missing or invalid credentials", Body: errorResponseBody{}},
+	http.StatusTooManyRequests: {Description: "Rate limit exceeded. See the Retry-After header", Body: errorResponseBody{}},
+	http.StatusInternalServerError: {Description: "Internal error. Quote the request id when reporting it", Body: errorResponseBody{}},
+}
+
+var stringSchema = map[string]any{"type": "string"}
+var emailSchema = map[string]any{"type": "string", "format": "email"}
+var integerSchema = map[string]any{"type": "integer"}
+var timestampSchema = map[string]any{"type": "string", "format": "date-time"}
+
+// routeSpecs must have an entry for every route in router(), keyed by method and gin path
+var routeSpecs = map[string]routeSpec{
+	"GET /healthz": {
+		Summary: "Liveness probe",
+		Public: true,
+		Responses: map[int]responseSpec{http.StatusOK: {Description: "The process is up", Body: healthResponseBody{}}},
+	},
+	"GET /readyz": {
+		Summary: "Readiness probe",
+		Public: true,
+		Responses: map[int]responseSpec{
+			http.StatusOK: {Description: "The database is reachable and fully migrated", Body: healthResponseBody{}},
+			http.StatusServiceUnavailable: {Description: "A component is not ready", Body: healthResponseBody{}},
+		},
+	},
+	"GET /metrics": {
+		Summary: "Prometheus metrics",
+		Public: true,
+		Responses: map[int]responseSpec{http.StatusOK: {Description: "Metrics in the Prometheus text format", Body: "", ContentType: "text/plain"}},
+	},
+	"GET /api/openapi.json": {
+		Summary: "This document",
+		Public: true,
+		Responses: map[int]responseSpec{http.StatusOK: {Description: "The OpenAPI document", Body: map[string]any{}}},
+	},
+	"POST /api/register": {
+		Summary: "Register students to a teacher",
+		RequestBody: models.StudentRegistrationData[string]{},
+		Responses: map[int]responseSpec{
+			http.StatusNoContent: {Description: "The students were registered"},
+			http.StatusBadRequest: errorResponse,
+			http.StatusForbidden: errorResponse,
+			http.StatusConflict: errorResponse,
+		},
+	},
+	"POST /api/register/batch": {
+		Summary: "Register students to several teachers, reporting the outcome of each entry",
+		RequestBody: registerStudentsBatchRequestBody{},
+		Responses: map[int]responseSpec{
+			http.StatusOK: {Description: "Each entry's outcome, including failed ones", Body: registerStudentsBatchSuccessBody{}},
+			http.StatusBadRequest: errorResponse,
+		},
+	},
+	"GET /api/commonstudents": {
+		Summary: "Retrieve the students registered to every given teacher",
+		Parameters: []parameterSpec{{Name: "teacher", In: "query", Description: "A teacher's email. Repeat for several teachers", Required: true, Example: []string{"tom@gmail.com"}, Schema: map[string]any{"type": "array", "items": emailSchema}}},
+		Responses: map[int]responseSpec{
+			http.StatusOK: {Description: "The common students", Body: commonStudentsSuccessBody{}},
+			http.StatusBadRequest: errorResponse,
+		},
+	},
+	"GET /api/students": {
+		Summary: "Search students by email, suspension and teacher",
+		Parameters: append([]parameterSpec{
+			{Name: "q", In: "query", Description: "Matches emails that start with, or are similar to, this text. Prefix matches come first", Schema: stringSchema},
+			{Name: "suspended", In: "query", Schema: map[string]any{"type": "boolean"}},
+			{Name: "teacher", In: "query", Description: "Matches the students registered to this teacher", Schema: emailSchema},
+		}, paginationParameters...),
+		Responses: map[int]responseSpec{
+			http.StatusOK: {Description: "The matching students", Body: searchStudentsSuccessBody{}},
+			http.StatusBadRequest: errorResponse,
+			http.StatusForbidden: errorResponse,
+		},
+	},
+	"GET /api/students/:student": {
+		Summary: "Retrieve a student, including the id that identifies them across email changes",
+		Parameters: []parameterSpec{studentEmailPathParameter},
+		Responses: map[int]responseSpec{
+			http.StatusOK: {Description: "The student", Body: models.Student{}},
+			http.StatusBadRequest: errorResponse,
+		},
+	},
+	"GET /api/students/:student/teachers": {
+		Summary: "Retrieve the teachers a student is registered to",
+		Parameters: []parameterSpec{studentEmailPathParameter},
+		Responses: map[int]responseSpec{
+			http.StatusOK: {Description: "The teachers, in alphabetical order", Body: studentTeachersSuccessBody{}},
+			http.StatusBadRequest: errorResponse,
+		},
+	},
+	"GET /api/commonteachers": {
+		Summary: "Retrieve the teachers every given student is registered to",
+		Parameters: []parameterSpec{{Name: "student", In: "query", Description: "A student's email. Repeat for several students", Required: true, Example: []string{"jerry@gmail.com"}, Schema: map[string]any{"type": "array", "items": emailSchema}}},
+		Responses: map[int]responseSpec{
+			http.StatusOK: {Description: "The common teachers, in alphabetical order", Body: studentTeachersSuccessBody{}},
+			http.StatusBadRequest: errorResponse,
+		},
+	},
+	"POST /api/suspend": {
+		Summary: "Suspend a student",
+		RequestBody: models.StudentSuspensionData[string]{},
+		Responses: map[int]responseSpec{
+			http.StatusNoContent: {Description: "The student was suspended"},
+			http.StatusBadRequest: errorResponse,
+			http.StatusForbidden: errorResponse,
+		},
+	},
+	"POST /api/retrievefornotifications": {
+		Summary: "Retrieve the students who can receive a notification",
+		RequestBody: models.RetrieveForNotificationsData{},
+		Responses: map[int]responseSpec{
+			http.StatusOK: {Description: "The recipients. With dryRun, nothing is sent and every student the notification concerns is listed with why they would, or would not, receive it", Body: retrieveForNotificationsSuccessBody{}},
+			http.StatusAccepted: {Description: "With sendAt, the notification was scheduled. Its recipients are resolved when it is sent", Body: models.ScheduledNotification{}},
+			http.StatusBadRequest: errorResponse,
+			http.StatusForbidden: errorResponse,
+		},
+	},
+	"PATCH /api/students/:student": {
+		Summary: "Change a student's email",
+		Parameters: []parameterSpec{{Name: "student", In: "path", Description: "The student's id", Required: true, Schema: map[string]any{"type": "string", "format": "uuid"}}},
+		RequestBody: models.StudentUpdateData{},
+		Responses: map[int]responseSpec{
+			http.StatusOK: {Description: "The updated student", Body: models.Student{}},
+			http.StatusBadRequest: errorResponse,
+			http.StatusForbidden: errorResponse,
+			http.StatusNotFound: errorResponse,
+			http.StatusConflict: errorResponse,
+		},
+	},
+	"GET /api/notifications/scheduled": {
+		Summary: "List the notifications waiting to be sent, the earliest first",
+		Parameters: append([]parameterSpec{
+			{Name: "teacher", In: "query", Description: "Defaults to the caller for teachers. Only administrators may leave it out", Schema: emailSchema},
+		}, paginationParameters...),
+		Responses: map[int]responseSpec{
+			http.StatusOK: {Description: "The pending notifications", Body: scheduledNotificationsSuccessBody{}},
+			http.StatusBadRequest: errorResponse,
+			http.StatusForbidden: errorResponse,
+		},
+	},
+	"DELETE /api/notifications/scheduled/:id": {
+		Summary: "Cancel a notification that has not been sent yet",
+		Parameters: []parameterSpec{{Name: "id", In: "path", Required: true, Schema: map[string]any{"type": "integer", "minimum": 1}}},
+		Responses: map[int]responseSpec{
+			http.StatusOK: {Description: "The cancelled notification", Body: models.ScheduledNotification{}},
+			http.StatusBadRequest: errorResponse,
+			http.StatusNotFound: {Description: "No pending notification of the caller's has the id", Body: errorResponseBody{}},
+		},
+	},
+	"GET /api/audit": {
+		Summary: "Query the audit log, most recent events first",
+		Parameters: append([]parameterSpec{
+			{Name: "actor", In: "query", Schema: stringSchema},
+			{Name: "action", In: "query", Schema: map[string]any{"type": "string", "enum": []string{"register", "register_batch", "suspend", "unsuspend", "notify", "notify_preview", "update_student", "cancel_notification"}}},
+			{Name: "target", In: "query", Description: "An email the event was about", Schema: emailSchema},
+			{Name: "targetId", In: "query", Description: "The id of a record the event was about, e.g. a student whose email was changed", Schema: stringSchema},
+			{Name: "outcome", In: "query", Schema: map[string]any{"type": "string", "enum": []string{"ok", "rejected", "error"}}},
+			{Name: "since", In: "query", Schema: timestampSchema},
+			{Name: "until", In: "query", Schema: timestampSchema},
+		}, paginationParameters...),
+		Responses: map[int]responseSpec{
+			http.StatusOK: {Description: "The matching events", Body: auditEventsSuccessBody{}},
+			http.StatusBadRequest: errorResponse,
+			http.StatusForbidden: errorResponse,
+		},
+	},
+	"GET /api/reports/teachers": {
+		Summary: "Count the students, and suspended students, of every teacher",
+		Parameters: []parameterSpec{reportFormatParameter},
+		Responses: map[int]responseSpec{
+			http.StatusOK: {Description: "One row per teacher, in alphabetical order", Body: teacherReportSuccessBody{}},
+			http.StatusBadRequest: errorResponse,
+			http.StatusForbidden: errorResponse,
+		},
+	},
+	"GET /api/reports/suspensions": {
+		Summary: "Count the students, and suspended students",
+		Parameters: []parameterSpec{reportFormatParameter},
+		Responses: map[int]responseSpec{
+			http.StatusOK: {Description: "The counts", Body: models.SuspensionReport{}},
+			http.StatusBadRequest: errorResponse,
+			http.StatusForbidden: errorResponse,
+		},
+	},
+	"GET /api/reports/notifications": {
+		Summary: "Count the notifications each teacher sent per week, and their average number of recipients",
+		Parameters: []parameterSpec{
+			{Name: "since", In: "query", Schema: timestampSchema},
+			{Name: "until", In: "query", Schema: timestampSchema},
+			reportFormatParameter,
+		},
+		Responses: map[int]responseSpec{
+			http.StatusOK: {Description: "One row per teacher and week, most recent week first", Body: notificationReportSuccessBody{}},
+			http.StatusBadRequest: errorResponse,
+			http.StatusForbidden: errorResponse,
+		},
+	},
+	"GET /api/v2/teachers/:email/students": {
+		Summary: "List the students registered to a teacher",
+		Parameters: []parameterSpec{emailPathParameter},
+		Responses: map[int]responseSpec{
+			http.StatusOK: {Description: "The teacher's students, in alphabetical order", Body: teacherStudentsSuccessBody{}},
+			http.StatusBadRequest: errorResponse,
+		},
+	},
+	"POST /api/v2/teachers/:email/students": {
+		Summary: "Register students to a teacher",
+		Parameters: []parameterSpec{emailPathParameter},
+		RequestBody: addTeacherStudentsRequestBody{},
+		Responses: map[int]responseSpec{
+			http.StatusNoContent: {Description: "The students were registered"},
+			http.StatusBadRequest: errorResponse,
+			http.StatusForbidden: errorResponse,
+			http.StatusConflict: errorResponse,
+		},
+	},
+	"GET /api/v2/students/:email/suspension": {
+		Summary: "Check whether a student is suspended",
+		Parameters: []parameterSpec{emailPathParameter},
+		Responses: map[int]responseSpec{
+			http.StatusOK: {Description: "The student's suspension", Body: studentSuspensionSuccessBody{}},
+			http.StatusBadRequest: errorResponse,
+		},
+	},
+	"PUT /api/v2/students/:email/suspension": {
+		Summary: "Suspend a student",
+		Parameters: []parameterSpec{emailPathParameter},
+		Responses: map[int]responseSpec{
+			http.StatusNoContent: {Description: "The student is suspended"},
+			http.StatusBadRequest: errorResponse,
+			http.StatusForbidden: errorResponse,
+		},
+	},
+	"DELETE /api/v2/students/:email/suspension": {
+		Summary: "Lift a student's suspension",
+		Parameters: []parameterSpec{emailPathParameter},
+		Responses: map[int]responseSpec{
+			http.StatusNoContent: {Description: "The student is not suspended"},
+			http.StatusBadRequest: errorResponse,
+			http.StatusForbidden: errorResponse,
+		},
+	},
+	"POST /api/v2/notifications": {
+		Summary: "Send a notification, returning the students who receive it",
+		RequestBody: models.RetrieveForNotificationsData{},
+		Responses: map[int]responseSpec{
+			http.StatusOK: {Description: "With dryRun, nothing is sent and every student the notification concerns is listed with why they would, or would not, receive it", Body: retrieveForNotificationsSuccessBody{}},
+			http.StatusCreated: {Description: "The recipients", Body: retrieveForNotificationsSuccessBody{}},
+			http.StatusAccepted: {Description: "With sendAt, the notification was scheduled. Its recipients are resolved when it is sent", Body: models.ScheduledNotification{}},
+			http.StatusBadRequest: errorResponse,
+			http.StatusForbidden: errorResponse,
+		},
+	},
+	"POST /graphql": {
+		Summary: "Run a GraphQL query over teachers, students, registrations and notifications",
+		RequestBody: graphQLRequestBody{},
+		Responses: map[int]responseSpec{
+			http.StatusOK: {Description: "The query's data and errors. Fields the caller may not read are errors with the code adminRoleRequired or notYourself", Body: graphql.Result{}},
+			http.StatusBadRequest: {Description: fmt.Sprintf("The body is not a GraphQL request, or the query nests more than %d fields deep", maxGraphQLQueryDepth), Body: errorResponseBody{}},
+		},
+	},
+}
+
+var paginationParameters = []parameterSpec{
+	{Name: "limit", In: "query", Schema: map[string]any{"type": "integer", "minimum": 1, "maximum": maxPageLimit, "default": defaultPageLimit}},
+	{Name: "offset", In: "query", Schema: map[string]any{"type": "integer", "minimum": 0, "default": 0}},
+}
+
+var reportFormatParameter = parameterSpec{Name: "format", In: "query", Description: "csv answers with text/csv instead of JSON", Schema: map[string]any{"type": "string", "enum": reportFormats, "default": "json"}}
+
+var emailPathParameter = parameterSpec{Name: "email", In: "path", Required: true, Schema: emailSchema}
+
+// Routes under /api/students/ share one parameter name, as OpenAPI treats paths that differ only by parameter
+// names as the same path. GET takes the student's email and PATCH their id
+var studentEmailPathParameter = parameterSpec{Name: "student", In: "path", Description: "The student's email", Required: true, Schema: emailSchema}
+
+var ginPathParameter = regexp.MustCompile(`:([A-Za-z0-9_]+)`)
+
+// buildOpenAPIDocument describes routes (as returned by gin.Engine.Routes) in OpenAPI 3
+func buildOpenAPIDocument(routes gin.RoutesInfo) map[string]any {
+	schemas := map[string]any{}
+	paths := map[string]map[string]any{}
+
+	for _, route := range routes {
+		spec, documented := routeSpecs[route.Method+" "+route.Path]
+		if !documented { continue }
+
+		path := ginPathParameter.ReplaceAllString(route.Path, "{$1}")
+		if paths[path] == nil {
+			paths[path] = map[string]any{}
+		}
+		paths[path][strings.ToLower(route.Method)] = spec.operation(schemas)
+	}
+
+	return map[string]any{
+		"openapi": "3.0.3",
+		"info": map[string]any{
+			"title": "OneCV teacher administration API",
+			"version": "1.0.0",
+		},
+		"paths": paths,
+		"components": map[string]any{
+			"schemas": schemas,
+			"securitySchemes": map[string]any{
+				"bearerAuth": map[string]any{"type": "http", "scheme": "bearer", "bearerFormat": "JWT"},
+				"apiKeyAuth": map[string]any{"type": "apiKey", "in": "header", "name": apiKeyHeader},
+			},
+		},
+	}
+}
+
+func (spec routeSpec) operation(schemas map[string]any) map[string]any {
+	operation := map[string]any{"summary": spec.Summary}
+
+	if len(spec.Parameters) > 0 {
+		parameters := []any{}
+		for _, parameter := range spec.Parameters {
+			documented := map[string]any{"name": parameter.Name, "in": parameter.In, "required": parameter.Required, "schema": parameter.Schema}
+			if parameter.Description != "" {
+				documented["description"] = parameter.Description
+			}
+			if parameter.Example != nil {
+				documented["example"] = parameter.Example
+			}
+			parameters = append(parameters, documented)
+		}
+		operation["parameters"] = parameters
+	}
+
+	if spec.RequestBody != nil {
+		operation["requestBody"] = map[string]any{
+			"required": true,
+			"content": map[string]any{"application/json": map[string]any{"schema": schemaFor(reflect.TypeOf(spec.RequestBody), schemas)}},
+		}
+	}
+
+	responses := map[string]any{}
+	addResponse := func(status int, response responseSpec) {
+		documented := map[string]any{"description": response.Description}
+		if response.Body != nil {
+			contentType := response.ContentType
+			if contentType == "" {
+				contentType = "application/json"
+			}
+			documented["content"] = map[string]any{contentType: map[string]any{"schema": schemaFor(reflect.TypeOf(response.Body), schemas)}}
+		}
+		responses[strconv.Itoa(status)] = documented
+	}
+	for status, response := range spec.Responses {
+		addResponse(status, response)
+	}
+	if !spec.Public {
+		for status, response := range commonErrorResponses {
+			addResponse(status, response)
+		}
+		operation["security"] = []any{map[string]any{"bearerAuth": []string{}}, map[string]any{"apiKeyAuth": []string{}}}
+	}
+	operation["responses"] = responses
+
+	return operation
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// schemaFor returns the JSON schema of values of type t as encoding/json would write them. Named structs
+// are added to schemas and referenced, e.g. StudentRegistrationData[string] becomes StudentRegistrationData
+func schemaFor(t reflect.Type, schemas map[string]any) map[string]any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == timeType:
+		return timestampSchema
+	case t.Kind() == reflect.String:
+		return stringSchema
+	case t.Kind() == reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case t.Kind() >= reflect.Int && t.Kind() <= reflect.Uint64:
+		return integerSchema
+	case t.Kind() == reflect.Float32 || t.Kind() == reflect.Float64:
+		return map[string]any{"type": "number"}
+	case t.Kind() == reflect.Slice || t.Kind() == reflect.Array:
+		return map[string]any{"type": "array", "items": schemaFor(t.Elem(), schemas)}
+	case t.Kind() == reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": schemaFor(t.Elem(), schemas)}
+	case t.Kind() == reflect.Interface:
+		return map[string]any{}
+	case t.Kind() != reflect.Struct:
+		return map[string]any{}
+	}
+
+	name := schemaName(t)
+	reference := map[string]any{"$ref": "#/components/schemas/" + name}
+	if _, exists := schemas[name]; exists {
+		return reference
+	}
+	schemas[name] = map[string]any{} // Placeholder, in case the struct refers to itself
+
+	properties := map[string]any{}
+	required := []string{}
+	isRequest := hasBindingTags(t)
+	for index := 0; index < t.NumField(); index++ {
+		field := t.Field(index)
+		if !field.IsExported() { continue }
+
+		jsonName, options, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if jsonName == "-" { continue }
+		if jsonName == "" {
+			jsonName = field.Name
+		}
+
+		properties[jsonName] = schemaFor(field.Type, schemas)
+		if (isRequest && strings.Contains(field.Tag.Get("binding"), "required")) || (!isRequest && !strings.Contains(options, "omitempty")) {
+			required = append(required, jsonName)
+		}
+	}
+	sort.Strings(required)
+
+	schema := map[string]any{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	schemas[name] = schema
+	return reference
+}
+
+// Request structs mark required fields with binding tags, so their other fields are optional.
+// Response structs always include fields without omitempty
+func hasBindingTags(t reflect.Type) bool {
+	for index := 0; index < t.NumField(); index++ {
+		if t.Field(index).Tag.Get("binding") != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// Go names instantiated generics Name[T], which is not a valid component name
+func schemaName(t reflect.Type) string {
+	name, _, _ := strings.Cut(t.Name(), "[")
+	if name == "" {
+		return "Object"
+	}
+	return name
+}
+
+func getOpenAPIDocument(router *gin.Engine) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, buildOpenAPIDocument(router.Routes()))
+	}
+}
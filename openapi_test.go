@@ -0,0 +1,88 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestEveryRouteIsDocumented(t *testing.T) {
+	for _, route := range testRouter.Routes() {
+		if _, documented := routeSpecs[route.Method+" "+route.Path]; !documented {
+			t.Errorf("%s %s has no entry in routeSpecs", route.Method, route.Path)
+		}
+	}
+
+	routes := map[string]bool{}
+	for _, route := range testRouter.Routes() {
+		routes[route.Method+" "+route.Path] = true
+	}
+	for route := range routeSpecs {
+		if !routes[route] {
+			t.Errorf("routeSpecs documents %s, which is not in router()", route)
+		}
+	}
+}
+
+func TestGetOpenAPIDocument(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest("GET", "/api/openapi.json", nil)
+	if err != nil {
+		t.Fatalf("building request: %v", err)
+	}
+
+	testRouter.ServeHTTP(recorder, request)
+
+	if recorder.Code != 200 {
+		t.Fatalf("wrong response code:\nwant: 200\n got: %v", recorder.Code)
+	}
+
+	var document struct {
+		Paths map[string]map[string]any `json:"paths"`
+		Components struct {
+			Schemas map[string]struct {
+				Required []string `json:"required"`
+			} `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &document); err != nil {
+		t.Fatalf("parsing document: %v", err)
+	}
+
+	for _, method := range []string{"get", "patch"} {
+		if _, exists := document.Paths["/api/students/{student}"][method]; !exists {
+			t.Errorf("path parameters must use the OpenAPI {student} syntax, and %s must be under /api/students/{student}", method)
+		}
+	}
+
+	// Paths that differ only by parameter names are the same path to OpenAPI
+	templates := map[string]string{}
+	for path := range document.Paths {
+		template := regexp.MustCompile(`\{[^}]+\}`).ReplaceAllString(path, "{}")
+		if other, exists := templates[template]; exists {
+			t.Errorf("%s and %s are the same path with different parameter names", path, other)
+		}
+		templates[template] = path
+	}
+
+	// Every schema that is referenced must be defined
+	for _, match := range regexp.MustCompile(`#/components/schemas/([A-Za-z]+)`).FindAllStringSubmatch(recorder.Body.String(), -1) {
+		if _, defined := document.Components.Schemas[match[1]]; !defined {
+			t.Errorf("schema %s is referenced but not defined", match[1])
+		}
+	}
+
+	for schema, wantRequired := range map[string]string{
+		"StudentRegistrationData": "students teacher",
+		"RetrieveForNotificationsData": "notification teacher",
+		"errorResponseBody": "message",
+		"Student": "email id suspended",
+	} {
+		if got := strings.Join(document.Components.Schemas[schema].Required, " "); got != wantRequired {
+			t.Errorf("wrong required properties for %s:\nwant: %s\n got: %s", schema, wantRequired, got)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"math"
+
+	"onecv-go-backend/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Every listing pages the same way, so that clients can share the code that walks through them
+const defaultPageLimit = 50
+const maxPageLimit = 500
+
+// paginationBody is returned with every listing, next to the page of items
+type paginationBody struct {
+	Limit int `json:"limit"`
+	Offset int `json:"offset"`
+	// The offset of the next page. Absent on the last page
+	NextOffset *int `json:"nextOffset,omitempty"`
+}
+
+// parsePagination reads the limit and offset query parameters shared by every listing
+func parsePagination(c *gin.Context) (models.Page, error) {
+	limit, err := parseIntQuery(c, "limit", defaultPageLimit, 1, maxPageLimit)
+	if err != nil { return models.Page{}, err }
+
+	offset, err := parseIntQuery(c, "offset", 0, 0, math.MaxInt32)
+	if err != nil { return models.Page{}, err }
+
+	return models.Page{Limit: limit, Offset: offset}, nil
+}
+
+func newPaginationBody(page models.Page, hasMore bool) paginationBody {
+	pagination := paginationBody{Limit: page.Limit, Offset: page.Offset}
+	if hasMore {
+		nextOffset := page.Offset + page.Limit
+		pagination.NextOffset = &nextOffset
+	}
+	return pagination
+}
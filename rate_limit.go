@@ -0,0 +1,198 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"onecv-go-backend/config"
+	"onecv-go-backend/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+type rateLimitStore interface {
+	// take takes a token from the bucket named key. When none is left it reports how long until one is
+	take(ctx context.Context, key string, limit config.RateLimit) (allowed bool, retryAfter time.Duration, err error)
+}
+
+func newRateLimitStore(cfg config.RateLimitConfig) rateLimitStore {
+	if cfg.Store == "postgres" {
+		return postgresRateLimitStore{}
+	}
+	return newMemoryRateLimitStore(time.Now)
+}
+
+// rateLimit limits each client to its route's token bucket, answering 429 with Retry-After once it is empty.
+// Authenticated clients are told apart by API key or token subject, anonymous ones by IP address.
+// Requests are let through if the store fails, so that the limiter cannot take the API down
+func rateLimit(cfg config.RateLimitConfig, store rateLimitStore) gin.HandlerFunc {
+	if !cfg.Enabled {
+		return func(c *gin.Context) { c.Next() }
+	}
+
+	return func(c *gin.Context) {
+		route := c.Request.Method + " " + c.FullPath()
+		limit, hasRouteLimit := cfg.Routes[route]
+		if !hasRouteLimit {
+			limit = cfg.Default
+		}
+
+		applyRateLimit(c, store, route, rateLimitClient(c), limit)
+	}
+}
+
+// Names the bucket each IP address shares across routes
+const perIPRoute = "*"
+
+// rateLimitIP limits each IP address to one token bucket across every route. It runs before authentication, so that
+// requests with wrong credentials, which never reach rateLimit, are limited too
+func rateLimitIP(cfg config.RateLimitConfig, store rateLimitStore) gin.HandlerFunc {
+	if !cfg.Enabled {
+		return func(c *gin.Context) { c.Next() }
+	}
+
+	return func(c *gin.Context) {
+		applyRateLimit(c, store, perIPRoute, "ip:"+c.ClientIP(), cfg.PerIP)
+	}
+}
+
+func applyRateLimit(c *gin.Context, store rateLimitStore, route string, client string, limit config.RateLimit) {
+	allowed, retryAfter, err := store.take(c.Request.Context(), route+"|"+client, limit)
+	if err != nil {
+		requestLogger(c).Error("Unable to apply the rate limit", "route", route, "error", err)
+		c.Next()
+		return
+	}
+
+	if !allowed {
+		seconds := int(math.Ceil(retryAfter.Seconds()))
+		c.Header("Retry-After", strconv.Itoa(seconds))
+		respondWithError(c, fmt.Errorf(customErrors["rateLimited"].Message, errors.New("rateLimited"), seconds))
+		c.Abort()
+		return
+	}
+
+	c.Next()
+}
+
+func rateLimitClient(c *gin.Context) string {
+	authenticated, ok := getPrincipal(c)
+	return rateLimitKey(authenticated, ok, c.ClientIP())
+}
+
+// rateLimitKey names a client's buckets after its API key or token subject, or its IP address when anonymous
+func rateLimitKey(authenticated principal, ok bool, clientIP string) string {
+	if ok {
+		if authenticated.Method == "apiKey" {
+			return "apiKey:" + authenticated.Subject
+		}
+		return "user:" + authenticated.Subject
+	}
+	return "ip:" + clientIP
+}
+
+type tokenBucket struct {
+	tokens float64
+	updatedAt time.Time
+	limit config.RateLimit
+}
+
+// memoryRateLimitStore keeps buckets in this process, so each instance has its own limits
+type memoryRateLimitStore struct {
+	mutex sync.Mutex
+	buckets map[string]*tokenBucket
+	now func() time.Time
+}
+
+// Full buckets are dropped once there are this many, so that idle clients do not pile up
+const maxIdleTokenBuckets = 10000
+
+func newMemoryRateLimitStore(now func() time.Time) *memoryRateLimitStore {
+	return &memoryRateLimitStore{buckets: map[string]*tokenBucket{}, now: now}
+}
+
+func (store *memoryRateLimitStore) take(ctx context.Context, key string, limit config.RateLimit) (bool, time.Duration, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	now := store.now()
+	bucket, exists := store.buckets[key]
+	if !exists {
+		if len(store.buckets) >= maxIdleTokenBuckets {
+			store.dropFullBuckets(now)
+		}
+		bucket = &tokenBucket{tokens: float64(limit.Burst), updatedAt: now, limit: limit}
+		store.buckets[key] = bucket
+	}
+
+	bucket.tokens = math.Min(float64(limit.Burst), bucket.tokens+now.Sub(bucket.updatedAt).Seconds()*limit.Rate)
+	bucket.updatedAt = now
+
+	if bucket.tokens < 1 {
+		return false, time.Duration((1 - bucket.tokens) / limit.Rate * float64(time.Second)), nil
+	}
+	bucket.tokens--
+	return true, 0, nil
+}
+
+// dropFullBuckets forgets buckets that would have refilled by now. Forgetting one is harmless, as it
+// is recreated full
+func (store *memoryRateLimitStore) dropFullBuckets(now time.Time) {
+	for key, bucket := range store.buckets {
+		if bucket.tokens+now.Sub(bucket.updatedAt).Seconds()*bucket.limit.Rate >= float64(bucket.limit.Burst) {
+			delete(store.buckets, key)
+		}
+	}
+}
+
+// postgresRateLimitStore keeps buckets in the database, so that limits hold across instances
+type postgresRateLimitStore struct{}
+
+func (postgresRateLimitStore) take(ctx context.Context, key string, limit config.RateLimit) (bool, time.Duration, error) {
+	return models.TakeRateLimitToken(ctx, key, limit.Rate, limit.Burst)
+}
+
+// How often idle buckets are deleted from the postgres store
+const rateLimitExpiryInterval = time.Minute
+
+// rateLimitRefillWindow is the longest any bucket takes to refill from empty
+func rateLimitRefillWindow(cfg config.RateLimitConfig) time.Duration {
+	window := math.Max(float64(cfg.Default.Burst)/cfg.Default.Rate, float64(cfg.PerIP.Burst)/cfg.PerIP.Rate)
+	for _, limit := range cfg.Routes {
+		window = math.Max(window, float64(limit.Burst)/limit.Rate)
+	}
+	return time.Duration(window * float64(time.Second))
+}
+
+// expireRateLimitBuckets deletes postgres buckets idle for longer than the refill window, until ctx is cancelled.
+// Every instance may run it, as deleting a full bucket is harmless
+func expireRateLimitBuckets(ctx context.Context, cfg config.RateLimitConfig) {
+	idleFor := rateLimitRefillWindow(cfg)
+	ticker := time.NewTicker(rateLimitExpiryInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		deleted, err := models.DeleteIdleRateLimitBuckets(ctx, idleFor)
+		if err != nil {
+			if ctx.Err() == nil {
+				slog.ErrorContext(ctx, "Unable to delete idle rate limit buckets", "error", err)
+			}
+			continue
+		}
+		if deleted > 0 {
+			slog.InfoContext(ctx, "Deleted idle rate limit buckets", "count", deleted)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"onecv-go-backend/config"
+	"onecv-go-backend/models"
+	"regexp"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/pashagolub/pgxmock/v3"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+func TestMemoryRateLimitStore(t *testing.T) {
+	now := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	store := newMemoryRateLimitStore(func() time.Time { return now })
+	limit := config.RateLimit{Rate: 0.5, Burst: 2}
+
+	steps := []struct {
+		stepDesc string
+		elapsed time.Duration
+		key string
+		wantAllowed bool
+		wantRetryAfter time.Duration
+	}{
+		{"First request of the burst", 0, "tom", true, 0},
+		{"Last request of the burst", 0, "tom", true, 0},
+		{"Bucket is empty", 0, "tom", false, 2 * time.Second},
+		{"Other clients have their own bucket", 0, "jerry", true, 0},
+		{"Bucket is half refilled", time.Second, "tom", false, time.Second},
+		{"Bucket has refilled one token", time.Second, "tom", true, 0},
+		{"Bucket never holds more than the burst", time.Hour, "tom", true, 0},
+		{"Burst is still limited", 0, "tom", true, 0},
+		{"Bucket is empty again", 0, "tom", false, 2 * time.Second},
+	}
+
+	for _, step := range steps {
+		now = now.Add(step.elapsed)
+		allowed, retryAfter, err := store.take(context.Background(), step.key, limit)
+		if err != nil {
+			t.Fatalf("%s: taking a token: %v", step.stepDesc, err)
+		}
+		if allowed != step.wantAllowed || retryAfter != step.wantRetryAfter {
+			t.Errorf("%s:\nwant: allowed %v, retry after %v\n got: allowed %v, retry after %v", step.stepDesc, step.wantAllowed, step.wantRetryAfter, allowed, retryAfter)
+		}
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	cfg := testConfig()
+	cfg.RateLimit.Routes = map[string]config.RateLimit{"GET /api/commonstudents": {Rate: 0.1, Burst: 2}}
+	limitedRouter := router(cfg, noop.NewTracerProvider(), newRateLimitStore(cfg.RateLimit))
+
+	// Invalid teachers are rejected before any query, so no stub database is needed
+	requests := []struct {
+		requestDesc string
+		clientIP string
+		wantCode int
+		wantRetryAfter string
+	}{
+		{"First request", "203.0.113.1", 400, ""},
+		{"Second request", "203.0.113.1", 400, ""},
+		{"Third request is limited", "203.0.113.1", 429, "10"},
+		{"Another client is not limited", "203.0.113.2", 400, ""},
+	}
+
+	for _, request := range requests {
+		recorder := httptest.NewRecorder()
+		httpRequest, err := http.NewRequest("GET", "/api/commonstudents?teacher=invalid", nil)
+		if err != nil {
+			t.Fatalf("building request: %v", err)
+		}
+		httpRequest.RemoteAddr = request.clientIP + ":40000"
+
+		limitedRouter.ServeHTTP(recorder, httpRequest)
+
+		if recorder.Code != request.wantCode || recorder.Header().Get("Retry-After") != request.wantRetryAfter {
+			t.Errorf("%s:\nwant: %d, Retry-After %q\n got: %d, Retry-After %q", request.requestDesc, request.wantCode, request.wantRetryAfter, recorder.Code, recorder.Header().Get("Retry-After"))
+		}
+	}
+
+	// Health checks are never limited
+	for index := 0; index < 5; index++ {
+		recorder := httptest.NewRecorder()
+		httpRequest, _ := http.NewRequest("GET", "/healthz", nil)
+		httpRequest.RemoteAddr = "203.0.113.1:40000"
+		limitedRouter.ServeHTTP(recorder, httpRequest)
+		if recorder.Code != 200 {
+			t.Fatalf("health check %d was limited: %d", index, recorder.Code)
+		}
+	}
+}
+
+func TestFailedAuthenticationIsRateLimited(t *testing.T) {
+	cfg := testAuthConfig()
+	cfg.RateLimit.PerIP = config.RateLimit{Rate: 0.1, Burst: 2}
+	limitedRouter := router(cfg, noop.NewTracerProvider(), newRateLimitStore(cfg.RateLimit))
+
+	// Wrong API keys are rejected before any query, so no stub database is needed
+	for index, wantCode := range []int{401, 401, 429} {
+		recorder := httptest.NewRecorder()
+		httpRequest, err := http.NewRequest("GET", "/api/commonstudents?teacher=tom@gmail.com", nil)
+		if err != nil {
+			t.Fatalf("building request: %v", err)
+		}
+		httpRequest.RemoteAddr = "203.0.113.1:40000"
+		httpRequest.Header.Set(apiKeyHeader, "guess-"+strconv.Itoa(index))
+
+		limitedRouter.ServeHTTP(recorder, httpRequest)
+
+		if recorder.Code != wantCode {
+			t.Errorf("attempt %d:\nwant: %d\n got: %d", index+1, wantCode, recorder.Code)
+		}
+	}
+}
+
+func TestRateLimitRefillWindow(t *testing.T) {
+	cfg := config.Default().RateLimit
+	// The default bucket refills in 2s, but notifications take 5s
+	if window := rateLimitRefillWindow(cfg); window != 5*time.Second {
+		t.Errorf("want a refill window of 5s, got %v", window)
+	}
+
+	cfg.Routes = nil
+	if window := rateLimitRefillWindow(cfg); window != 2*time.Second {
+		t.Errorf("want a refill window of 2s without route overrides, got %v", window)
+	}
+}
+
+func TestDeleteIdleRateLimitBuckets(t *testing.T) {
+	mock, err := pgxmock.NewConn()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mock.Close(context.Background())
+
+	mock.ExpectQuery(regexp.QuoteMeta("DELETE FROM rate_limit_bucket WHERE updated_at < now() - $1::float8 * interval '1 second' RETURNING 1")).
+		WithArgs(float64(5)).
+		WillReturnRows(pgxmock.NewRows([]string{"count"}).AddRow(3))
+	models.DB = mock
+
+	deleted, err := models.DeleteIdleRateLimitBuckets(context.Background(), 5*time.Second)
+	if err != nil {
+		t.Fatalf("deleting idle buckets: %v", err)
+	}
+	if deleted != 3 {
+		t.Errorf("want 3 buckets deleted, got %d", deleted)
+	}
+	checkQueryExpectations(mock, t)
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"onecv-go-backend/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// A whole school can be registered in a few batches, while a single batch cannot hold the database for long
+const maxRegistrationBatchSize = 100
+
+type registerStudentsBatchRequestBody struct {
+	Registrations []models.StudentRegistrationData[string] `json:"registrations" binding:"required,dive"`
+}
+
+type registrationResult struct {
+	Teacher string `json:"teacher"`
+	Students []string `json:"students"`
+	Registered bool `json:"registered"`
+	// Why the entry was not registered, as /api/register would have answered it
+	Error *errorResponseBody `json:"error,omitempty"`
+}
+
+type registerStudentsBatchSuccessBody struct {
+	Registered int `json:"registered"`
+	Failed int `json:"failed"`
+	Results []registrationResult `json:"results"`
+}
+
+// registerStudentsBatch registers each entry as /api/register would, in its own transaction, and reports
+// the outcome of every entry. It answers 200 as long as the batch itself is valid, even if every entry failed
+func registerStudentsBatch(c *gin.Context) {
+	var requestBody registerStudentsBatchRequestBody
+	if err := c.BindJSON(&requestBody); err != nil {
+		err := fmt.Errorf(customErrors["invalidDataType"].Message, errors.New("invalidDataType"))
+		respondWithError(c, err)
+		return
+	}
+
+	if batchSize := len(requestBody.Registrations); batchSize == 0 || batchSize > maxRegistrationBatchSize {
+		err := fmt.Errorf(customErrors["invalidBatchSize"].Message, errors.New("invalidBatchSize"), maxRegistrationBatchSize, batchSize)
+		respondWithError(c, err)
+		return
+	}
+
+	//Parameter validation (normalize, remove duplicates, check for @gmail.com), entry by entry
+	registrations := make([]models.StudentRegistrationData[string], len(requestBody.Registrations))
+	validationErrors := make([]error, len(requestBody.Registrations))
+	auditTargets := []string{}
+	for index, studentRegistrationData := range requestBody.Registrations {
+		registrations[index], validationErrors[index] = prepareStudentRegistration(studentRegistrationData)
+		auditTargets = append(append(auditTargets, registrations[index].Teacher), registrations[index].Students...)
+	}
+	setAuditTargets(c, removeDuplicateStr(auditTargets)...)
+
+	//Register the students of each entry
+	responseBody := registerStudentsBatchSuccessBody{Results: []registrationResult{}}
+	for index, studentRegistrationData := range registrations {
+		err := validationErrors[index]
+		if err == nil {
+			err = authorizeTeacher(c, studentRegistrationData.Teacher, "register students")
+		}
+		if err == nil {
+			err = models.RegisterStudents(c.Request.Context(), studentRegistrationData)
+		}
+
+		result := registrationResult{Teacher: studentRegistrationData.Teacher, Students: studentRegistrationData.Students, Registered: err == nil}
+		if err != nil {
+			_, errorBody := newErrorResponse(c, err)
+			errorBody.RequestID = ""
+			result.Error = &errorBody
+			responseBody.Failed++
+		} else {
+			responseBody.Registered++
+		}
+		responseBody.Results = append(responseBody.Results, result)
+	}
+
+	c.IndentedJSON(http.StatusOK, responseBody)
+}
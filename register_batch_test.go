@@ -0,0 +1,113 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"testing"
+
+	"onecv-go-backend/models"
+
+	"github.com/pashagolub/pgxmock/v3"
+)
+
+func TestRegisterStudentsBatch(t *testing.T) {
+	testCases := []routeTestCase{
+		{
+			"Each entry succeeds or fails on its own",
+			"POST", "/api/register/batch",
+			map[string]any{"registrations": []any{
+				map[string]any{"teacher": "Tom@Gmail.com", "students": []string{"jerry@gmail.com"}},
+				map[string]any{"teacher": "quacker@gmail.com", "students": []string{"spikegmail.com"}},
+				map[string]any{"teacher": "nobody@gmail.com", "students": []string{"tyke@gmail.com"}},
+			}},
+			func(mock pgxmock.PgxConnIface) {
+				addCheckTeacherExistsQuery(mock, "tom@gmail.com", true)
+				addCheckStudentExistsQueries(mock, []string{"jerry@gmail.com"}, []bool{true})
+				addCheckTeacherStudentRelationshipExistsQueries(mock, "tom@gmail.com", []string{"jerry@gmail.com"}, []bool{false})
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta("INSERT INTO teacher_student_relationship(teacher, student) VALUES ($1, $2)")).WithArgs("tom@gmail.com", "jerry@gmail.com").WillReturnRows(pgxmock.NewRows([]string{"id", "teacher", "student"}))
+				mock.ExpectCommit()
+
+				addCheckTeacherExistsQuery(mock, "nobody@gmail.com", false)
+				addCheckStudentExistsQueries(mock, []string{"tyke@gmail.com"}, []bool{true})
+
+				addRecordAuditEventQuery(mock, "register_batch", []string{"tom@gmail.com", "jerry@gmail.com", "quacker@gmail.com", "spikegmail.com", "nobody@gmail.com", "tyke@gmail.com"}, 200, nil)
+			},
+			200,
+			map[string]any{
+				"registered": float64(1),
+				"failed": float64(2),
+				"results": []any{
+					map[string]any{"teacher": "tom@gmail.com", "students": []any{"jerry@gmail.com"}, "registered": true},
+					map[string]any{"teacher": "quacker@gmail.com", "students": []any{"spikegmail.com"}, "registered": false,
+						"error": map[string]any{"message": fmt.Errorf(customErrors["invalidEmail"].Message, errors.New("invalidEmail"), "'spikegmail.com'").Error()}},
+					map[string]any{"teacher": "nobody@gmail.com", "students": []any{"tyke@gmail.com"}, "registered": false,
+						"error": map[string]any{"message": fmt.Errorf(models.CustomErrors["nonExistentTeacher"].Message, errors.New("nonExistentTeacher"), "nobody@gmail.com").Error()}},
+				},
+			},
+		},
+		{
+			"An entry that fails part way registers none of its students",
+			"POST", "/api/register/batch",
+			map[string]any{"registrations": []any{
+				map[string]any{"teacher": "tom@gmail.com", "students": []string{"jerry@gmail.com", "spike@gmail.com"}},
+				map[string]any{"teacher": "quacker@gmail.com", "students": []string{"tyke@gmail.com"}},
+			}},
+			func(mock pgxmock.PgxConnIface) {
+				addCheckTeacherExistsQuery(mock, "tom@gmail.com", true)
+				addCheckStudentExistsQueries(mock, []string{"jerry@gmail.com", "spike@gmail.com"}, []bool{true, true})
+				addCheckTeacherStudentRelationshipExistsQueries(mock, "tom@gmail.com", []string{"jerry@gmail.com", "spike@gmail.com"}, []bool{false, false})
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta("INSERT INTO teacher_student_relationship(teacher, student) VALUES ($1, $2)")).WithArgs("tom@gmail.com", "jerry@gmail.com").WillReturnRows(pgxmock.NewRows([]string{"id", "teacher", "student"}))
+				mock.ExpectQuery(regexp.QuoteMeta("INSERT INTO teacher_student_relationship(teacher, student) VALUES ($1, $2)")).WithArgs("tom@gmail.com", "spike@gmail.com").WillReturnError(errors.New("connection reset"))
+				mock.ExpectRollback()
+
+				addCheckTeacherExistsQuery(mock, "quacker@gmail.com", true)
+				addCheckStudentExistsQueries(mock, []string{"tyke@gmail.com"}, []bool{true})
+				addCheckTeacherStudentRelationshipExistsQueries(mock, "quacker@gmail.com", []string{"tyke@gmail.com"}, []bool{false})
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta("INSERT INTO teacher_student_relationship(teacher, student) VALUES ($1, $2)")).WithArgs("quacker@gmail.com", "tyke@gmail.com").WillReturnRows(pgxmock.NewRows([]string{"id", "teacher", "student"}))
+				mock.ExpectCommit()
+
+				addRecordAuditEventQuery(mock, "register_batch", []string{"tom@gmail.com", "jerry@gmail.com", "spike@gmail.com", "quacker@gmail.com", "tyke@gmail.com"}, 200, nil)
+			},
+			200,
+			map[string]any{
+				"registered": float64(1),
+				"failed": float64(1),
+				"results": []any{
+					map[string]any{"teacher": "tom@gmail.com", "students": []any{"jerry@gmail.com", "spike@gmail.com"}, "registered": false,
+						"error": map[string]any{"message": internalErrorMessage}},
+					map[string]any{"teacher": "quacker@gmail.com", "students": []any{"tyke@gmail.com"}, "registered": true},
+				},
+			},
+		},
+		{
+			"Empty batch",
+			"POST", "/api/register/batch",
+			map[string]any{"registrations": []any{}},
+			func(mock pgxmock.PgxConnIface) {
+				addRecordAuditEventQuery(mock, "register_batch", []string{}, 400, nil)
+			},
+			customErrors["invalidBatchSize"].Status,
+			errorResponseBody{Message: fmt.Errorf(customErrors["invalidBatchSize"].Message, errors.New("invalidBatchSize"), maxRegistrationBatchSize, 0).Error()},
+		},
+		{
+			"Entry without students",
+			"POST", "/api/register/batch",
+			map[string]any{"registrations": []any{map[string]any{"teacher": "tom@gmail.com"}}},
+			func(mock pgxmock.PgxConnIface) {
+				addRecordAuditEventQuery(mock, "register_batch", []string{}, 400, nil)
+			},
+			customErrors["invalidDataType"].Status,
+			errorResponseBody{Message: fmt.Errorf(customErrors["invalidDataType"].Message, errors.New("invalidDataType")).Error()},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.testCaseDesc, func(t *testing.T) {
+			runRouteTest(t, testRouter, testCase)
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"onecv-go-backend/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+type teacherReportSuccessBody struct {
+	Teachers []models.TeacherReportRow `json:"teachers"`
+}
+
+type notificationReportSuccessBody struct {
+	Weeks []models.NotificationReportRow `json:"weeks"`
+}
+
+// Reports are JSON unless ?format=csv is given, so that they can be opened in a spreadsheet
+var reportFormats = []string{"json", "csv"}
+
+func getTeacherReport(c *gin.Context) {
+	format, err := parseReportFormat(c)
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	rows, err := models.GetTeacherReport(c.Request.Context())
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	respondWithReport(c, format, "teachers", teacherReportSuccessBody{rows}, rows)
+}
+
+func getSuspensionReport(c *gin.Context) {
+	format, err := parseReportFormat(c)
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	report, err := models.GetSuspensionReport(c.Request.Context())
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	respondWithReport(c, format, "suspensions", report, []models.SuspensionReport{report})
+}
+
+func getNotificationReport(c *gin.Context) {
+	format, err := parseReportFormat(c)
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	//Parameter validation (timestamps are RFC 3339)
+	since, err := parseTimeQuery(c, "since")
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+	until, err := parseTimeQuery(c, "until")
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	rows, err := models.GetNotificationReport(c.Request.Context(), since, until)
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	respondWithReport(c, format, "notifications", notificationReportSuccessBody{rows}, rows)
+}
+
+func parseReportFormat(c *gin.Context) (string, error) {
+	format := c.DefaultQuery("format", "json")
+	for _, reportFormat := range reportFormats {
+		if format == reportFormat { return format, nil }
+	}
+	return "", fmt.Errorf(customErrors["invalidQueryParameter"].Message, errors.New("invalidQueryParameter"), "format", "one of "+strings.Join(reportFormats, ", "))
+}
+
+// respondWithReport answers with body as JSON, or with rows (a slice of structs) as CSV
+func respondWithReport(c *gin.Context, format string, name string, body any, rows any) {
+	if format != "csv" {
+		c.IndentedJSON(http.StatusOK, body)
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.csv"`, name))
+	c.Status(http.StatusOK)
+	c.Writer.Header().Set("Content-Type", "text/csv; charset=utf-8")
+
+	writer := csv.NewWriter(c.Writer)
+	writer.WriteAll(csvRecords(reflect.ValueOf(rows)))
+	if err := writer.Error(); err != nil {
+		requestLogger(c).Error("Unable to write report", "report", name, "error", err)
+	}
+}
+
+// csvRecords turns a slice of structs into a header of their JSON names followed by one record per struct
+func csvRecords(rows reflect.Value) [][]string {
+	rowType := rows.Type().Elem()
+
+	header := []string{}
+	for index := 0; index < rowType.NumField(); index++ {
+		name, _, _ := strings.Cut(rowType.Field(index).Tag.Get("json"), ",")
+		header = append(header, name)
+	}
+
+	records := [][]string{header}
+	for rowIndex := 0; rowIndex < rows.Len(); rowIndex++ {
+		row := rows.Index(rowIndex)
+		record := []string{}
+		for index := 0; index < row.NumField(); index++ {
+			switch value := row.Field(index).Interface().(type) {
+			case time.Time:
+				record = append(record, value.Format(time.RFC3339))
+			case float64:
+				record = append(record, strconv.FormatFloat(value, 'f', -1, 64))
+			default:
+				record = append(record, fmt.Sprint(value))
+			}
+		}
+		records = append(records, record)
+	}
+	return records
+}
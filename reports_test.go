@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+
+	"onecv-go-backend/models"
+
+	"github.com/pashagolub/pgxmock/v3"
+)
+
+const teacherReportQuery = `
+		SELECT teacher.email, COUNT(student.email), COUNT(student.email) FILTER (WHERE student.suspended)
+		FROM teacher
+		LEFT JOIN teacher_student_relationship ON teacher_student_relationship.teacher = teacher.email
+		LEFT JOIN student ON student.email = teacher_student_relationship.student
+		GROUP BY teacher.email
+		ORDER BY teacher.email
+	`
+
+const notificationReportQuery = `
+		SELECT teacher, date_trunc('week', sent_at AT TIME ZONE 'UTC') AT TIME ZONE 'UTC' AS week, COUNT(*), AVG(cardinality(recipients))::float8
+		FROM notification
+		WHERE status = 'sent' AND ($1::timestamptz IS NULL OR sent_at >= $1) AND ($2::timestamptz IS NULL OR sent_at < $2)
+		GROUP BY teacher, week
+		ORDER BY week DESC, teacher
+	`
+
+func addTeacherReportQuery(mock pgxmock.PgxConnIface) {
+	mock.ExpectQuery(regexp.QuoteMeta(teacherReportQuery)).
+		WillReturnRows(pgxmock.NewRows([]string{"email", "students", "suspended"}).AddRow("quacker@gmail.com", 0, 0).AddRow("tom@gmail.com", 2, 1))
+}
+
+func TestReports(t *testing.T) {
+	week := time.Date(2024, time.March, 4, 0, 0, 0, 0, time.UTC)
+	since := time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)
+
+	testCases := []routeTestCase{
+		{
+			"Students per teacher",
+			"GET", "/api/reports/teachers", nil,
+			addTeacherReportQuery,
+			200,
+			map[string]any{"teachers": []any{
+				map[string]any{"teacher": "quacker@gmail.com", "students": float64(0), "suspendedStudents": float64(0)},
+				map[string]any{"teacher": "tom@gmail.com", "students": float64(2), "suspendedStudents": float64(1)},
+			}},
+		},
+		{
+			"Suspended students",
+			"GET", "/api/reports/suspensions", nil,
+			func(mock pgxmock.PgxConnIface) {
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*), COUNT(*) FILTER (WHERE suspended) FROM student")).
+					WillReturnRows(pgxmock.NewRows([]string{"count", "suspended"}).AddRow(3, 1))
+			},
+			200,
+			map[string]any{"students": float64(3), "suspendedStudents": float64(1)},
+		},
+		{
+			"Notifications per teacher per week",
+			"GET", "/api/reports/notifications?since=2024-03-01T00:00:00Z", nil,
+			func(mock pgxmock.PgxConnIface) {
+				mock.ExpectQuery(regexp.QuoteMeta(notificationReportQuery)).WithArgs(&since, (*time.Time)(nil)).
+					WillReturnRows(pgxmock.NewRows([]string{"teacher", "week", "count", "avg"}).AddRow("tom@gmail.com", week, 2, 1.5))
+			},
+			200,
+			map[string]any{"weeks": []any{
+				map[string]any{"teacher": "tom@gmail.com", "week": "2024-03-04T00:00:00Z", "notifications": float64(2), "averageRecipients": 1.5},
+			}},
+		},
+		{
+			"Invalid format",
+			"GET", "/api/reports/teachers?format=xml", nil,
+			nil,
+			customErrors["invalidQueryParameter"].Status,
+			errorResponseBody{Message: fmt.Errorf(customErrors["invalidQueryParameter"].Message, errors.New("invalidQueryParameter"), "format", "one of json, csv").Error()},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.testCaseDesc, func(t *testing.T) {
+			runRouteTest(t, testRouter, testCase)
+		})
+	}
+}
+
+func TestReportsAsCSV(t *testing.T) {
+	mock, err := pgxmock.NewConn()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mock.Close(context.Background())
+	addTeacherReportQuery(mock)
+	models.DB = mock
+
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest("GET", "/api/reports/teachers?format=csv", nil)
+	if err != nil {
+		t.Fatalf("building request: %v", err)
+	}
+	testRouter.ServeHTTP(recorder, request)
+
+	checkQueryExpectations(mock, t)
+	wantBody := "teacher,students,suspendedStudents\nquacker@gmail.com,0,0\ntom@gmail.com,2,1\n"
+	if recorder.Code != http.StatusOK || recorder.Body.String() != wantBody {
+		t.Errorf("wrong response:\nwant: 200 %q\n got: %d %q", wantBody, recorder.Code, recorder.Body.String())
+	}
+	if contentType := recorder.Header().Get("Content-Type"); contentType != "text/csv; charset=utf-8" {
+		t.Errorf("wrong content type: %q", contentType)
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"onecv-go-backend/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// scheduleNotification records a notification for the scheduler to send at sendAt, answering 202 as nothing has been sent yet
+func scheduleNotification(c *gin.Context, retrieveForNotificationsProcessedData models.RetrieveForNotificationsProcessedData[string], sendAt time.Time) {
+	if !sendAt.After(time.Now()) {
+		respondWithError(c, fmt.Errorf(customErrors["sendAtInPast"].Message, errors.New("sendAtInPast"), sendAt.Format(time.RFC3339)))
+		return
+	}
+
+	notification, err := models.ScheduleNotification(c.Request.Context(), retrieveForNotificationsProcessedData, sendAt)
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	c.IndentedJSON(http.StatusAccepted, notification)
+}
+
+type scheduledNotificationsSuccessBody struct {
+	Notifications []models.ScheduledNotification `json:"notifications"`
+	Pagination paginationBody `json:"pagination"`
+}
+
+func getScheduledNotifications(c *gin.Context) {
+	//Parameter validation (teacher is a valid email, limit and offset are bounded integers)
+	filter := models.ScheduledNotificationFilter{Teacher: normalizeEmail(c.Query("teacher"))}
+
+	// Teachers see their own notifications unless they ask for another teacher's, which is then refused
+	authenticated, ok := getPrincipal(c)
+	if filter.Teacher == "" && ok && authenticated.Role == teacherRole {
+		filter.Teacher = normalizeEmail(authenticated.Subject)
+	}
+
+	if filter.Teacher != "" {
+		if err := checkEmails([]string{filter.Teacher}); err != nil {
+			respondWithError(c, err)
+			return
+		}
+		if err := authorizeTeacher(c, filter.Teacher, "list scheduled notifications"); err != nil {
+			respondWithError(c, err)
+			return
+		}
+	} else if err := authorizeAdmin(authenticated, ok, "list every teacher's scheduled notifications"); err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	var err error
+	if filter.Page, err = parsePagination(c); err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	notifications, hasMore, err := models.ListScheduledNotifications(c.Request.Context(), filter)
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, scheduledNotificationsSuccessBody{notifications, newPaginationBody(filter.Page, hasMore)})
+}
+
+func cancelScheduledNotification(c *gin.Context) {
+	//Parameter validation (the id is a positive integer)
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id < 1 {
+		respondWithError(c, fmt.Errorf(customErrors["invalidID"].Message, errors.New("invalidID"), c.Param("id")))
+		return
+	}
+
+	notification, err := models.GetScheduledNotification(c.Request.Context(), id)
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+	setAuditTargets(c, notification.Teacher)
+
+	// Another teacher's notification is reported as missing, so that which ids exist cannot be probed
+	if err := authorizeTeacher(c, notification.Teacher, "cancel notifications"); err != nil {
+		respondWithError(c, fmt.Errorf(models.CustomErrors["nonExistentScheduledNotification"].Message, errors.New("nonExistentScheduledNotification"), id))
+		return
+	}
+
+	// Fails if the scheduler sent the notification in the meantime
+	notification, err = models.CancelScheduledNotification(c.Request.Context(), id)
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, notification)
+}
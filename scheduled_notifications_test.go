@@ -0,0 +1,253 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+
+	"onecv-go-backend/models"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/pashagolub/pgxmock/v3"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+var scheduledNotificationColumns = []string{"id", "teacher", "notification", "send_at", "status", "mentions"}
+
+func TestScheduledNotifications(t *testing.T) {
+	sendAt := time.Date(2099, time.January, 5, 8, 0, 0, 0, time.UTC)
+	pendingRow := func() *pgxmock.Rows {
+		return pgxmock.NewRows(scheduledNotificationColumns).AddRow(int64(7), "tom@gmail.com", "Hello @spike@gmail.com", sendAt, "pending", []string{"spike@gmail.com"})
+	}
+	scheduledNotification := map[string]any{
+		"id": float64(7), "teacher": "tom@gmail.com", "notification": "Hello @spike@gmail.com",
+		"sendAt": "2099-01-05T08:00:00Z", "status": "pending", "mentions": []any{"spike@gmail.com"},
+	}
+	cancelledNotification := map[string]any{}
+	for key, value := range scheduledNotification {
+		cancelledNotification[key] = value
+	}
+	cancelledNotification["status"] = "cancelled"
+
+	testCases := []routeTestCase{
+		{
+			"Schedule a notification",
+			"POST", "/api/retrievefornotifications", models.RetrieveForNotificationsData{Teacher: "tom@gmail.com", Notification: "Hello @spike@gmail.com", SendAt: &sendAt},
+			func(mock pgxmock.PgxConnIface) {
+				addCheckTeacherExistsQuery(mock, "tom@gmail.com", true)
+				addCheckStudentExistsQueries(mock, []string{"spike@gmail.com"}, []bool{true})
+				mock.ExpectQuery(regexp.QuoteMeta(`
+		INSERT INTO notification(teacher, notification, mentions, send_at, status, sent_at)
+		VALUES ($1, $2, $3, $4, 'pending', NULL)
+		RETURNING id, teacher, notification, send_at, status, mentions`)).
+					WithArgs("tom@gmail.com", "Hello @spike@gmail.com", []string{"spike@gmail.com"}, sendAt).WillReturnRows(pendingRow())
+				addRecordAuditEventQuery(mock, "notify", []string{"tom@gmail.com", "spike@gmail.com"}, 202, nil)
+			},
+			202,
+			scheduledNotification,
+		},
+		{
+			"Cannot schedule a notification in the past",
+			"POST", "/api/retrievefornotifications", models.RetrieveForNotificationsData{Teacher: "tom@gmail.com", Notification: "Hello", SendAt: &time.Time{}},
+			func(mock pgxmock.PgxConnIface) {
+				addRecordAuditEventQuery(mock, "notify", []string{"tom@gmail.com"}, customErrors["sendAtInPast"].Status, nil)
+			},
+			customErrors["sendAtInPast"].Status,
+			errorResponseBody{Message: fmt.Errorf(customErrors["sendAtInPast"].Message, errors.New("sendAtInPast"), "0001-01-01T00:00:00Z").Error()},
+		},
+		{
+			"Schedule a notification over v2",
+			"POST", "/api/v2/notifications", models.RetrieveForNotificationsData{Teacher: "tom@gmail.com", Notification: "Hello @spike@gmail.com", SendAt: &sendAt},
+			func(mock pgxmock.PgxConnIface) {
+				addCheckTeacherExistsQuery(mock, "tom@gmail.com", true)
+				addCheckStudentExistsQueries(mock, []string{"spike@gmail.com"}, []bool{true})
+				mock.ExpectQuery(regexp.QuoteMeta(`
+		INSERT INTO notification(teacher, notification, mentions, send_at, status, sent_at)
+		VALUES ($1, $2, $3, $4, 'pending', NULL)
+		RETURNING id, teacher, notification, send_at, status, mentions`)).
+					WithArgs("tom@gmail.com", "Hello @spike@gmail.com", []string{"spike@gmail.com"}, sendAt).WillReturnRows(pendingRow())
+				addRecordAuditEventQuery(mock, "notify", []string{"tom@gmail.com", "spike@gmail.com"}, 202, nil)
+			},
+			202,
+			scheduledNotification,
+		},
+		{
+			"Cannot schedule a notification in the past over v2",
+			"POST", "/api/v2/notifications", models.RetrieveForNotificationsData{Teacher: "tom@gmail.com", Notification: "Hello", SendAt: &time.Time{}},
+			func(mock pgxmock.PgxConnIface) {
+				addRecordAuditEventQuery(mock, "notify", []string{"tom@gmail.com"}, customErrors["sendAtInPast"].Status, nil)
+			},
+			customErrors["sendAtInPast"].Status,
+			errorResponseBody{Message: fmt.Errorf(customErrors["sendAtInPast"].Message, errors.New("sendAtInPast"), "0001-01-01T00:00:00Z").Error()},
+		},
+		{
+			"List a teacher's pending notifications",
+			"GET", "/api/notifications/scheduled?teacher=Tom@Gmail.com", nil,
+			func(mock pgxmock.PgxConnIface) {
+				mock.ExpectQuery(regexp.QuoteMeta(`
+		SELECT id, teacher, notification, send_at, status, mentions
+		FROM notification
+		WHERE status = 'pending' AND teacher = $1
+		ORDER BY send_at, id
+		LIMIT $2 OFFSET $3
+	`)).WithArgs("tom@gmail.com", defaultPageLimit+1, 0).WillReturnRows(pendingRow())
+			},
+			200,
+			map[string]any{
+				"notifications": []any{scheduledNotification},
+				"pagination": map[string]any{"limit": float64(defaultPageLimit), "offset": float64(0)},
+			},
+		},
+		{
+			"Cancel a pending notification",
+			"DELETE", "/api/notifications/scheduled/7", nil,
+			func(mock pgxmock.PgxConnIface) {
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT id, teacher, notification, send_at, status, mentions FROM notification WHERE id = $1 AND status = 'pending'")).
+					WithArgs(int64(7)).WillReturnRows(pendingRow())
+				mock.ExpectQuery(regexp.QuoteMeta("UPDATE notification SET status = 'cancelled' WHERE id = $1 AND status = 'pending' RETURNING id, teacher, notification, send_at, status, mentions")).
+					WithArgs(int64(7)).WillReturnRows(pgxmock.NewRows(scheduledNotificationColumns).AddRow(int64(7), "tom@gmail.com", "Hello @spike@gmail.com", sendAt, "cancelled", []string{"spike@gmail.com"}))
+				addRecordAuditEventQuery(mock, "cancel_notification", []string{"tom@gmail.com"}, 200, nil)
+			},
+			200,
+			cancelledNotification,
+		},
+		{
+			"Cannot cancel a notification that was sent",
+			"DELETE", "/api/notifications/scheduled/8", nil,
+			func(mock pgxmock.PgxConnIface) {
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT id, teacher, notification, send_at, status, mentions FROM notification WHERE id = $1 AND status = 'pending'")).
+					WithArgs(int64(8)).WillReturnRows(pgxmock.NewRows(scheduledNotificationColumns))
+				addRecordAuditEventQuery(mock, "cancel_notification", []string{}, models.CustomErrors["nonExistentScheduledNotification"].Status, nil)
+			},
+			models.CustomErrors["nonExistentScheduledNotification"].Status,
+			errorResponseBody{Message: fmt.Errorf(models.CustomErrors["nonExistentScheduledNotification"].Message, errors.New("nonExistentScheduledNotification"), int64(8)).Error()},
+		},
+		{
+			"Invalid id",
+			"DELETE", "/api/notifications/scheduled/abc", nil,
+			func(mock pgxmock.PgxConnIface) {
+				addRecordAuditEventQuery(mock, "cancel_notification", []string{}, customErrors["invalidID"].Status, nil)
+			},
+			customErrors["invalidID"].Status,
+			errorResponseBody{Message: fmt.Errorf(customErrors["invalidID"].Message, errors.New("invalidID"), "abc").Error()},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.testCaseDesc, func(t *testing.T) {
+			runRouteTest(t, testRouter, testCase)
+		})
+	}
+}
+
+// A teacher must not be able to tell another teacher's notifications from ids that do not exist
+func TestCancelScheduledNotificationOfAnotherTeacher(t *testing.T) {
+	cfg := testAuthConfig()
+	authRouter := router(cfg, noop.NewTracerProvider(), newRateLimitStore(cfg.RateLimit))
+	claims := tokenClaims{Role: teacherRole, RegisteredClaims: jwt.RegisteredClaims{Subject: "tom@gmail.com", Issuer: "onecv-test", ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour))}}
+	token := signTestToken(t, claims, testJWTSecret)
+
+	testCases := []struct {
+		testCaseDesc string
+		id int64
+		rows *pgxmock.Rows
+		auditTargets []string
+	}{
+		{"Another teacher's notification", 7, pgxmock.NewRows(scheduledNotificationColumns).AddRow(int64(7), "quacker@gmail.com", "Hello", time.Date(2099, time.January, 5, 8, 0, 0, 0, time.UTC), "pending", []string{}), []string{"quacker@gmail.com"}},
+		{"A notification that does not exist", 8, pgxmock.NewRows(scheduledNotificationColumns), []string{}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testCaseDesc, func(t *testing.T) {
+			mock, err := pgxmock.NewConn()
+			if err != nil {
+				t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+			}
+			defer mock.Close(context.Background())
+
+			mock.ExpectQuery(regexp.QuoteMeta("SELECT id, teacher, notification, send_at, status, mentions FROM notification WHERE id = $1 AND status = 'pending'")).
+				WithArgs(tc.id).WillReturnRows(tc.rows)
+			addRecordAuditEventQueryAs(mock, "tom@gmail.com", "cancel_notification", tc.auditTargets, []string{}, models.CustomErrors["nonExistentScheduledNotification"].Status)
+			models.DB = mock
+
+			recorder := httptest.NewRecorder()
+			request, err := http.NewRequest("DELETE", fmt.Sprintf("/api/notifications/scheduled/%d", tc.id), http.NoBody)
+			if err != nil {
+				t.Fatalf("building request: %v", err)
+			}
+			request.Header.Set("Authorization", "Bearer "+token)
+
+			authRouter.ServeHTTP(recorder, request)
+
+			checkQueryExpectations(mock, t)
+			checkStatusAndResponse[errorResponseBody](recorder, t, testCaseStruct{
+				models.CustomErrors["nonExistentScheduledNotification"].Status,
+				errorResponseBody{Message: fmt.Errorf(models.CustomErrors["nonExistentScheduledNotification"].Message, errors.New("nonExistentScheduledNotification"), tc.id).Error()},
+			})
+		})
+	}
+}
+
+func TestSendDueNotifications(t *testing.T) {
+	now := time.Date(2099, time.January, 5, 8, 0, 0, 0, time.UTC)
+
+	mock, err := pgxmock.NewConn()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mock.Close(context.Background())
+
+	mock.ExpectQuery(regexp.QuoteMeta(`
+		SELECT id, teacher, notification, send_at, status, mentions
+		FROM notification
+		WHERE status = 'pending' AND send_at <= $1
+		ORDER BY send_at, id
+		LIMIT $2
+	`)).WithArgs(now, 10).WillReturnRows(pgxmock.NewRows(scheduledNotificationColumns).
+		AddRow(int64(7), "tom@gmail.com", "Hello @spike@gmail.com", now, "pending", []string{"spike@gmail.com"}).
+		AddRow(int64(8), "tom@gmail.com", "Hello @tyke@gmail.com", now, "pending", []string{"tyke@gmail.com"}).
+		AddRow(int64(9), "tom@gmail.com", "Hello", now, "pending", []string{}))
+
+	recordSent := func(id int64, status string, recipients []string, sent bool) {
+		rows := pgxmock.NewRows([]string{"id"})
+		if sent { rows.AddRow(id) }
+		mock.ExpectQuery(regexp.QuoteMeta("UPDATE notification SET status = $2, recipients = $3, sent_at = now() WHERE id = $1 AND status = 'pending' RETURNING id")).
+			WithArgs(id, status, recipients).WillReturnRows(rows)
+	}
+	addRegisteredStudentsQuery := func(students []string) {
+		mock.ExpectQuery(regexp.QuoteMeta(`
+		SELECT array_agg(DISTINCT student) AS students
+		FROM teacher_student_relationship
+		WHERE teacher = $1
+		GROUP BY teacher
+	`)).WithArgs("tom@gmail.com").WillReturnRows(pgxmock.NewRows([]string{"students"}).AddRow(students))
+	}
+
+	// Recipients are resolved at send time: jerry was suspended after the notification was scheduled
+	addCheckTeacherExistsQuery(mock, "tom@gmail.com", true)
+	addCheckStudentExistsQueries(mock, []string{"spike@gmail.com"}, []bool{true})
+	addRegisteredStudentsQuery([]string{"jerry@gmail.com"})
+	addCheckStudentSuspendedQuery(mock, "spike@gmail.com", false)
+	addCheckStudentSuspendedQuery(mock, "jerry@gmail.com", true)
+	recordSent(7, models.NotificationSent, []string{"spike@gmail.com"}, true)
+
+	// tyke has been removed since, so the notification fails
+	addCheckTeacherExistsQuery(mock, "tom@gmail.com", true)
+	addCheckStudentExistsQueries(mock, []string{"tyke@gmail.com"}, []bool{false})
+	recordSent(8, models.NotificationFailed, []string{}, true)
+
+	// Cancelled while its recipients were being resolved
+	addCheckTeacherExistsQuery(mock, "tom@gmail.com", true)
+	addRegisteredStudentsQuery([]string{"jerry@gmail.com"})
+	addCheckStudentSuspendedQuery(mock, "jerry@gmail.com", false)
+	recordSent(9, models.NotificationSent, []string{"jerry@gmail.com"}, false)
+
+	models.DB = mock
+	sendDueNotifications(context.Background(), now, 10)
+	checkQueryExpectations(mock, t)
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"onecv-go-backend/config"
+	"onecv-go-backend/models"
+)
+
+// runScheduler sends scheduled notifications once their send time has come, until ctx is cancelled. Every
+// instance may run it: each notification is only recorded as sent once
+func runScheduler(ctx context.Context, cfg config.SchedulerConfig) {
+	slog.Info("Scheduling notifications", "interval", cfg.Interval.String())
+	ticker := time.NewTicker(cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		sendDueNotifications(ctx, time.Now(), cfg.BatchSize)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// sendDueNotifications sends up to batchSize notifications due at now. Notifications that cannot be sent
+// because of a database error stay pending, and are retried on the next run
+func sendDueNotifications(ctx context.Context, now time.Time, batchSize int) {
+	notifications, err := models.GetDueNotifications(ctx, now, batchSize)
+	if err != nil {
+		if ctx.Err() == nil {
+			slog.ErrorContext(ctx, "Unable to look for due notifications", "error", err)
+		}
+		return
+	}
+
+	for _, notification := range notifications {
+		if ctx.Err() != nil { return }
+
+		status, recipients, err := models.SendScheduledNotification(ctx, notification)
+		switch {
+		case status == models.NotificationFailed:
+			slog.WarnContext(ctx, "Scheduled notification failed", "id", notification.ID, "teacher", notification.Teacher, "error", err)
+		case err != nil:
+			slog.ErrorContext(ctx, "Unable to send scheduled notification", "id", notification.ID, "error", err)
+		case status == models.NotificationSent:
+			slog.InfoContext(ctx, "Sent scheduled notification", "id", notification.ID, "teacher", notification.Teacher, "recipients", len(recipients))
+		}
+	}
+}
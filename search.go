@@ -0,0 +1,54 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"onecv-go-backend/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+type searchStudentsSuccessBody struct {
+	Students []models.StudentSearchResult `json:"students"`
+	Pagination paginationBody `json:"pagination"`
+}
+
+func searchStudents(c *gin.Context) {
+	filter := models.StudentSearchFilter{Query: strings.TrimSpace(c.Query("q"))}
+
+	//Parameter validation (suspended is a boolean, teacher is a valid email, limit and offset are bounded integers)
+	if value := c.Query("suspended"); value != "" {
+		suspended, err := strconv.ParseBool(value)
+		if err != nil {
+			respondWithError(c, fmt.Errorf(customErrors["invalidQueryParameter"].Message, errors.New("invalidQueryParameter"), "suspended", "true or false"))
+			return
+		}
+		filter.Suspended = &suspended
+	}
+
+	if teacher := c.Query("teacher"); teacher != "" {
+		filter.Teacher = normalizeEmail(teacher)
+		if err := checkEmails([]string{filter.Teacher}); err != nil {
+			respondWithError(c, err)
+			return
+		}
+	}
+
+	var err error
+	if filter.Page, err = parsePagination(c); err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	students, hasMore, err := models.SearchStudents(c.Request.Context(), filter)
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, searchStudentsSuccessBody{students, newPaginationBody(filter.Page, hasMore)})
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"testing"
+
+	"onecv-go-backend/models"
+
+	"github.com/pashagolub/pgxmock/v3"
+)
+
+var testStudentIDs = map[string]string{
+	"jerry@gmail.com": "5f0c1b7e-3c2a-4d8e-9b1a-2f6d7e8c9a01",
+	"jeremy@gmail.com": "5f0c1b7e-3c2a-4d8e-9b1a-2f6d7e8c9a02",
+}
+
+func expectSearchStudentsQuery(mock pgxmock.PgxConnIface, where string, orderBy string, args []any, emails []string) {
+	rows := pgxmock.NewRows([]string{"id", "email", "suspended"})
+	for _, email := range emails {
+		rows.AddRow(testStudentIDs[email], email, false)
+	}
+
+	mock.ExpectQuery(regexp.QuoteMeta(fmt.Sprintf(`
+		SELECT id, email, COALESCE(suspended, false)
+		FROM student
+		%s
+		ORDER BY %s
+		LIMIT $%d OFFSET $%d
+	`, where, orderBy, len(args)-1, len(args)))).WithArgs(args...).WillReturnRows(rows)
+}
+
+func TestSearchStudents(t *testing.T) {
+	testCases := []routeTestCase{
+		{
+			"Every student",
+			"GET", "/api/students", nil,
+			func(mock pgxmock.PgxConnIface) {
+				expectSearchStudentsQuery(mock, "", "email", []any{defaultPageLimit + 1, 0}, []string{"jerry@gmail.com"})
+			},
+			200,
+			map[string]any{
+				"students": []any{map[string]any{"id": testStudentIDs["jerry@gmail.com"], "email": "jerry@gmail.com", "suspended": false}},
+				"pagination": map[string]any{"limit": float64(defaultPageLimit), "offset": float64(0)},
+			},
+		},
+		{
+			"Search by partial email, suspension and teacher",
+			"GET", "/api/students?q=Jer_&suspended=false&teacher=Tom@Gmail.com&limit=1", nil,
+			func(mock pgxmock.PgxConnIface) {
+				addCheckTeacherExistsQuery(mock, "tom@gmail.com", true)
+				expectSearchStudentsQuery(mock,
+					"WHERE (lower(email::text) LIKE $1 OR lower(email::text) % $2) AND COALESCE(suspended, false) = $3 AND EXISTS (SELECT 1 FROM teacher_student_relationship WHERE student = student.email AND teacher = $4)",
+					"lower(email::text) LIKE $1 DESC, similarity(lower(email::text), $2) DESC, email",
+					[]any{`jer\_%`, "jer_", false, "tom@gmail.com", 2, 0},
+					[]string{"jerry@gmail.com", "jeremy@gmail.com"},
+				)
+			},
+			200,
+			map[string]any{
+				"students": []any{map[string]any{"id": testStudentIDs["jerry@gmail.com"], "email": "jerry@gmail.com", "suspended": false}},
+				"pagination": map[string]any{"limit": float64(1), "offset": float64(0), "nextOffset": float64(1)},
+			},
+		},
+		{
+			"Teacher that does not exist",
+			"GET", "/api/students?teacher=nobody@gmail.com", nil,
+			func(mock pgxmock.PgxConnIface) {
+				addCheckTeacherExistsQuery(mock, "nobody@gmail.com", false)
+			},
+			models.CustomErrors["nonExistentTeacher"].Status,
+			errorResponseBody{Message: fmt.Errorf(models.CustomErrors["nonExistentTeacher"].Message, errors.New("nonExistentTeacher"), "nobody@gmail.com").Error()},
+		},
+		{
+			"Invalid suspended filter",
+			"GET", "/api/students?suspended=maybe", nil,
+			nil,
+			customErrors["invalidQueryParameter"].Status,
+			errorResponseBody{Message: fmt.Errorf(customErrors["invalidQueryParameter"].Message, errors.New("invalidQueryParameter"), "suspended", "true or false").Error()},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.testCaseDesc, func(t *testing.T) {
+			runRouteTest(t, testRouter, testCase)
+		})
+	}
+}
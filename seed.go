@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"onecv-go-backend/models"
+
+	"gopkg.in/yaml.v3"
+)
+
+const defaultFixturePath = "fixtures/demo.json"
+
+type fixture struct {
+	Teachers      []string              `json:"teachers" yaml:"teachers"`
+	Students      []string              `json:"students" yaml:"students"`
+	Registrations []fixtureRegistration `json:"registrations" yaml:"registrations"`
+	Suspensions   []string              `json:"suspensions" yaml:"suspensions"`
+}
+
+type fixtureRegistration struct {
+	Teacher  string   `json:"teacher" yaml:"teacher"`
+	Students []string `json:"students" yaml:"students"`
+}
+
+func runSeedCommand(args []string) error {
+	if len(args) > 1 {
+		return errors.New("usage: seed [fixture.json|fixture.yaml]")
+	}
+
+	path := defaultFixturePath
+	if len(args) == 1 {
+		path = args[0]
+	}
+
+	seedFixture, err := loadFixture(path)
+	if err != nil { return fmt.Errorf("%s: %w", path, err) }
+
+	summary, err := seed(context.Background(), seedFixture)
+	if err != nil { return err }
+
+	fmt.Fprintf(os.Stdout, "Added %d teacher(s), %d student(s) and %d registration(s); suspended %d student(s)\n",
+		summary.TeachersAdded, summary.StudentsAdded, summary.RegistrationsAdded, len(seedFixture.Suspensions))
+	return nil
+}
+
+// loadFixture reads a JSON or YAML fixture (chosen by file extension) and normalizes/validates every email in it
+func loadFixture(path string) (fixture, error) {
+	contents, err := os.ReadFile(path)
+	if err != nil { return fixture{}, err }
+
+	var seedFixture fixture
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		err = json.Unmarshal(contents, &seedFixture)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(contents, &seedFixture)
+	default:
+		err = errors.New("fixtures must be .json, .yaml or .yml files")
+	}
+	if err != nil { return fixture{}, err }
+
+	if seedFixture.Teachers, err = normalizeAndValidateEmails(seedFixture.Teachers); err != nil { return fixture{}, err }
+	if seedFixture.Students, err = normalizeAndValidateEmails(seedFixture.Students); err != nil { return fixture{}, err }
+	if seedFixture.Suspensions, err = normalizeAndValidateEmails(seedFixture.Suspensions); err != nil { return fixture{}, err }
+
+	for index, registration := range seedFixture.Registrations {
+		emails, err := normalizeAndValidateEmails(append([]string{registration.Teacher}, registration.Students...))
+		if err != nil { return fixture{}, err }
+
+		seedFixture.Registrations[index] = fixtureRegistration{Teacher: emails[0], Students: emails[1:]}
+	}
+
+	return seedFixture, nil
+}
+
+// seed loads a fixture through the models layer. Rows that already exist are skipped,
+// so seeding the same fixture again leaves the database unchanged
+func seed(ctx context.Context, seedFixture fixture) (models.RosterImportSummary, error) {
+	summary := models.RosterImportSummary{}
+
+	var err error
+	summary.TeachersAdded, err = models.AddTeachersIfNotExist(ctx, seedFixture.Teachers)
+	if err != nil { return summary, err }
+
+	summary.StudentsAdded, err = models.AddStudentsIfNotExist(ctx, seedFixture.Students)
+	if err != nil { return summary, err }
+
+	roster := []models.RosterEntry{}
+	for _, registration := range seedFixture.Registrations {
+		for _, student := range registration.Students {
+			roster = append(roster, models.RosterEntry{Teacher: registration.Teacher, Student: student})
+		}
+	}
+
+	rosterSummary, err := models.ImportRoster(ctx, roster)
+	if err != nil { return summary, err }
+	summary.TeachersAdded += rosterSummary.TeachersAdded
+	summary.StudentsAdded += rosterSummary.StudentsAdded
+	summary.RegistrationsAdded = rosterSummary.RegistrationsAdded
+
+	for _, student := range seedFixture.Suspensions {
+		if err := models.SuspendStudent(ctx, models.StudentSuspensionData[string]{Student: student}); err != nil { return summary, err }
+	}
+
+	return summary, nil
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLoadFixture(t *testing.T) {
+	if _, err := loadFixture(defaultFixturePath); err != nil {
+		t.Errorf("loading %s: %v", defaultFixturePath, err)
+	}
+
+	path := filepath.Join(t.TempDir(), "fixture.yaml")
+	contents := `
+teachers: [Tom@Gmail.com]
+students: [jerry@gmail.com]
+registrations:
+  - teacher: tom@gmail.com
+    students: [Jerry@gmail.com]
+suspensions: [jerry@gmail.com]
+`
+	if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+		t.Fatalf("writing fixture: %v", err)
+	}
+
+	seedFixture, err := loadFixture(path)
+	if err != nil {
+		t.Fatalf("loading fixture: %v", err)
+	}
+
+	wantFixture := fixture{
+		Teachers: []string{"tom@gmail.com"},
+		Students: []string{"jerry@gmail.com"},
+		Registrations: []fixtureRegistration{{Teacher: "tom@gmail.com", Students: []string{"jerry@gmail.com"}}},
+		Suspensions: []string{"jerry@gmail.com"},
+	}
+	if !cmp.Equal(seedFixture, wantFixture) {
+		t.Errorf("wrong fixture:\nwant: %v\n got: %v", wantFixture, seedFixture)
+	}
+}
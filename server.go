@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"onecv-go-backend/config"
+	"onecv-go-backend/migrations"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+)
+
+func serve(cfg config.Config, pool *pgxpool.Pool, tracerProvider trace.TracerProvider) error {
+	if !cfg.Auth.Enabled {
+		slog.Warn("AUTH IS DISABLED: every endpoint, including suspending and updating students, is open to anyone. Set AUTH_ENABLED=true outside development")
+	}
+
+	// Bring the schema up to date before serving requests
+	if _, err := migrations.Up(context.Background(), pool); err != nil {
+		return fmt.Errorf("Unable to migrate the database. Err: %w", err)
+	}
+
+	// Shared by both transports, so that a client's limits hold whichever it uses
+	rateLimitStore := newRateLimitStore(cfg.RateLimit)
+
+	var grpcServer *grpc.Server
+	if cfg.Server.GRPCAddr != "" {
+		var err error
+		grpcServer, err = newGRPCServer(cfg, rateLimitStore)
+		if err != nil { return fmt.Errorf("Unable to start the gRPC server. Err: %w", err) }
+	}
+
+	listener, grpcListener, err := listen(cfg.Server)
+	if err != nil { return err }
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	grpcErrors := make(chan error, 1)
+	if grpcListener != nil {
+		go func() {
+			// Stop serving HTTP too if gRPC fails, rather than run half of the service
+			err := serveGRPC(ctx, grpcServer, grpcListener, cfg.Server.ShutdownTimeout)
+			stop()
+			grpcErrors <- err
+		}()
+	} else {
+		grpcErrors <- nil
+	}
+
+	var background sync.WaitGroup
+	if cfg.Scheduler.Enabled {
+		background.Add(1)
+		go func() {
+			defer background.Done()
+			runScheduler(ctx, cfg.Scheduler)
+		}()
+	}
+	if cfg.RateLimit.Enabled && cfg.RateLimit.Store == "postgres" {
+		background.Add(1)
+		go func() {
+			defer background.Done()
+			expireRateLimitBuckets(ctx, cfg.RateLimit)
+		}()
+	}
+
+	// The database pool is closed by main once serve returns, i.e. after in-flight requests have drained
+	// and the background jobs have stopped
+	err = runServer(ctx, newServer(cfg.Server, router(cfg, tracerProvider, rateLimitStore)), listener, cfg.Server)
+	stop()
+	background.Wait()
+	return errors.Join(err, <-grpcErrors)
+}
+
+// listen binds the HTTP address and, when set, the gRPC address. Both are bound before anything is served, so
+// that neither server runs without the other. grpcListener is nil when gRPC is disabled
+func listen(cfg config.ServerConfig) (listener net.Listener, grpcListener net.Listener, err error) {
+	listener, err = net.Listen("tcp", cfg.Addr)
+	if err != nil { return nil, nil, err }
+
+	if cfg.GRPCAddr != "" {
+		grpcListener, err = net.Listen("tcp", cfg.GRPCAddr)
+		if err != nil {
+			listener.Close()
+			return nil, nil, err
+		}
+	}
+
+	return listener, grpcListener, nil
+}
+
+func newServer(cfg config.ServerConfig, handler http.Handler) *http.Server {
+	return &http.Server{
+		Handler:           handler,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		ReadTimeout:       cfg.ReadTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+		TLSConfig:         &tls.Config{MinVersion: tls.VersionTLS12},
+		ErrorLog:          slog.NewLogLogger(slog.Default().Handler(), slog.LevelWarn),
+	}
+}
+
+// runServer serves until ctx is cancelled, then stops accepting connections and gives
+// in-flight requests up to the shutdown timeout to finish
+func runServer(ctx context.Context, server *http.Server, listener net.Listener, cfg config.ServerConfig) error {
+	serverErrors := make(chan error, 1)
+	go func() {
+		if cfg.TLSCertFile != "" {
+			slog.Info("Listening for HTTPS", "addr", listener.Addr().String())
+			serverErrors <- server.ServeTLS(listener, cfg.TLSCertFile, cfg.TLSKeyFile)
+		} else {
+			slog.Info("Listening for HTTP", "addr", listener.Addr().String())
+			serverErrors <- server.Serve(listener)
+		}
+	}()
+
+	select {
+	case err := <-serverErrors:
+		return err
+	case <-ctx.Done():
+	}
+
+	slog.Info("Shutting down, waiting for in-flight requests", "timeout", cfg.ShutdownTimeout.String())
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("Unable to shut down gracefully. Err: %w", err)
+	}
+	if err := <-serverErrors; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	slog.Info("Server stopped")
+	return nil
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"onecv-go-backend/config"
+)
+
+func TestRunServerDrainsInFlightRequests(t *testing.T) {
+	requestStarted := make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(requestStarted)
+		time.Sleep(100 * time.Millisecond)
+		io.WriteString(w, "done")
+	})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listening: %v", err)
+	}
+
+	cfg := config.Default().Server
+	ctx, cancel := context.WithCancel(context.Background())
+	serverStopped := make(chan error, 1)
+	go func() {
+		serverStopped <- runServer(ctx, newServer(cfg, handler), listener, cfg)
+	}()
+
+	responseBody := make(chan string, 1)
+	go func() {
+		response, err := http.Get("http://" + listener.Addr().String())
+		if err != nil {
+			responseBody <- err.Error()
+			return
+		}
+		defer response.Body.Close()
+		body, _ := io.ReadAll(response.Body)
+		responseBody <- string(body)
+	}()
+
+	// Shut down while the request is still being handled
+	<-requestStarted
+	cancel()
+
+	if body := <-responseBody; body != "done" {
+		t.Errorf("in-flight request was not drained:\nwant: done\n got: %s", body)
+	}
+	if err := <-serverStopped; err != nil {
+		t.Errorf("server did not shut down cleanly: %v", err)
+	}
+}
+
+func TestListenReleasesHTTPAddrWhenGRPCAddrIsTaken(t *testing.T) {
+	taken, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listening: %v", err)
+	}
+	defer taken.Close()
+
+	free, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listening: %v", err)
+	}
+	httpAddr := free.Addr().String()
+	free.Close()
+
+	if _, _, err := listen(config.ServerConfig{Addr: httpAddr, GRPCAddr: taken.Addr().String()}); err == nil {
+		t.Fatal("expected an error for a gRPC address that is already in use")
+	}
+
+	listener, err := net.Listen("tcp", httpAddr)
+	if err != nil {
+		t.Fatalf("the HTTP address was not released: %v", err)
+	}
+	listener.Close()
+}
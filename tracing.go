@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+
+	"onecv-go-backend/config"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+const tracerName = "onecv-go-backend"
+
+// newTracerProvider builds the provider for the configured exporter. The returned function flushes
+// any spans that have not been exported yet and must be called before exiting
+func newTracerProvider(cfg config.TracingConfig) (trace.TracerProvider, func(context.Context) error, error) {
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch cfg.Exporter {
+	case "stdout":
+		// Standard output is kept for the output of the CLI commands, which scripts may parse
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stderr))
+	case "otlp":
+		exporter, err = newOTLPExporter(cfg.OTLPEndpoint)
+	default:
+		return noop.NewTracerProvider(), func(context.Context) error { return nil }, nil
+	}
+	if err != nil { return nil, nil, fmt.Errorf("Unable to create the %s trace exporter. Err: %w", cfg.Exporter, err) }
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+		sdktrace.WithResource(resource.NewSchemaless(semconv.ServiceName(cfg.ServiceName))),
+	)
+
+	// Continue traces started by callers that send a traceparent header
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	return provider, provider.Shutdown, nil
+}
+
+// newOTLPExporter sends spans over OTLP/HTTP to a collector URL such as http://localhost:4318
+func newOTLPExporter(endpoint string) (sdktrace.SpanExporter, error) {
+	endpointURL, err := url.Parse(endpoint)
+	if err != nil { return nil, err }
+
+	options := []otlptracehttp.Option{otlptracehttp.WithEndpoint(endpointURL.Host)}
+	if endpointURL.Scheme == "http" {
+		options = append(options, otlptracehttp.WithInsecure())
+	}
+	if endpointURL.Path != "" && endpointURL.Path != "/" {
+		options = append(options, otlptracehttp.WithURLPath(endpointURL.Path))
+	}
+	return otlptracehttp.New(context.Background(), options...)
+}
+
+// traceRequests starts a span per request, named after the matched route, and hands it to the
+// handler through the request context so that the queries it issues become child spans
+func traceRequests(tracerProvider trace.TracerProvider) gin.HandlerFunc {
+	tracer := tracerProvider.Tracer(tracerName)
+
+	return func(c *gin.Context) {
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+
+		ctx := otel.GetTextMapPropagator().Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+		ctx, span := tracer.Start(ctx, c.Request.Method+" "+route,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPMethod(c.Request.Method),
+				semconv.HTTPRoute(route),
+				attribute.String("http.request_id", getRequestID(c)),
+			),
+		)
+		defer span.End()
+
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+
+		status := c.Writer.Status()
+		span.SetAttributes(semconv.HTTPStatusCode(status))
+		if status >= 500 {
+			span.SetStatus(codes.Error, fmt.Sprintf("responded with %d", status))
+		}
+	}
+}
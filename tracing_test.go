@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"onecv-go-backend/models"
+	"regexp"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v3"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestRequestsAreTraced(t *testing.T) {
+	spanRecorder := tracetest.NewSpanRecorder()
+	tracedRouter := router(testConfig(), sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spanRecorder)), newRateLimitStore(testConfig().RateLimit))
+
+	mock, err := pgxmock.NewConn()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mock.Close(context.Background())
+
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT email FROM teacher WHERE email = $1")).WithArgs("tom@gmail.com").WillReturnRows(mock.NewRows([]string{"email"}))
+	models.DB = mock
+
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest("GET", "/api/commonstudents?teacher=tom@gmail.com", nil)
+	if err != nil {
+		t.Fatalf("building request: %v", err)
+	}
+	request.Header.Set(requestIDHeader, "req-traced")
+
+	tracedRouter.ServeHTTP(recorder, request)
+
+	checkQueryExpectations(mock, t)
+
+	spans := spanRecorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("wrong number of spans:\nwant: 1\n got: %d", len(spans))
+	}
+	if got := spans[0].Name(); got != "GET /api/commonstudents" {
+		t.Errorf("wrong span name:\nwant: GET /api/commonstudents\n got: %s", got)
+	}
+
+	attributes := map[attribute.Key]attribute.Value{}
+	for _, keyValue := range spans[0].Attributes() {
+		attributes[keyValue.Key] = keyValue.Value
+	}
+	for key, want := range map[attribute.Key]string{"http.route": "/api/commonstudents", "http.request_id": "req-traced", "http.status_code": "400"} {
+		if got := attributes[key].Emit(); got != want {
+			t.Errorf("wrong %s attribute:\nwant: %s\n got: %s", key, want, got)
+		}
+	}
+}
+
+// The stub database does not run pgx tracers, so the handler calls the tracer installed by connectDatabase
+// itself, as pgx does around every query
+func TestQueriesAreTracedAsChildrenOfTheRequest(t *testing.T) {
+	spanRecorder := tracetest.NewSpanRecorder()
+	tracerProvider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spanRecorder))
+	queryTracer := models.QueryTracer{Tracer: tracerProvider.Tracer(tracerName)}
+
+	tracedRouter := gin.New()
+	tracedRouter.Use(assignRequestID(), traceRequests(tracerProvider))
+	tracedRouter.GET("/api/commonstudents", func(c *gin.Context) {
+		ctx := queryTracer.TraceQueryStart(c.Request.Context(), nil, pgx.TraceQueryStartData{SQL: "SELECT email FROM teacher WHERE email = $1"})
+		queryTracer.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{})
+		c.Status(http.StatusOK)
+	})
+
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest("GET", "/api/commonstudents", nil)
+	if err != nil {
+		t.Fatalf("building request: %v", err)
+	}
+
+	tracedRouter.ServeHTTP(recorder, request)
+
+	spans := spanRecorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("wrong number of spans:\nwant: 2\n got: %d", len(spans))
+	}
+	querySpan, requestSpan := spans[0], spans[1]
+	if got := querySpan.Name(); got != "none SELECT" {
+		t.Errorf("wrong query span name:\nwant: none SELECT\n got: %s", got)
+	}
+	if querySpan.Parent().SpanID() != requestSpan.SpanContext().SpanID() || querySpan.SpanContext().TraceID() != requestSpan.SpanContext().TraceID() {
+		t.Errorf("the query span is not a child of the request span %s", requestSpan.Name())
+	}
+}
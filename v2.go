@@ -0,0 +1,163 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"onecv-go-backend/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// The v2 API exposes the same operations as v1 as resources. v1 stays as it is for existing clients
+
+func addV2Routes(api *gin.RouterGroup) {
+	v2 := api.Group("/v2")
+	v2.GET("/teachers/:email/students", getTeacherStudents)
+	v2.POST("/teachers/:email/students", audit("register"), addTeacherStudents)
+	v2.GET("/students/:email/suspension", getStudentSuspension)
+	v2.PUT("/students/:email/suspension", audit("suspend"), requireAdmin("suspend students"), putStudentSuspension)
+	v2.DELETE("/students/:email/suspension", audit("unsuspend"), requireAdmin("unsuspend students"), deleteStudentSuspension)
+	v2.POST("/notifications", audit("notify"), createNotification)
+}
+
+type teacherStudentsSuccessBody struct {
+	Students []string `json:"students"`
+}
+
+func getTeacherStudents(c *gin.Context) {
+	teacher, ok := emailParam(c, "email")
+	if !ok { return }
+
+	students, err := models.GetTeacherStudents(c.Request.Context(), teacher)
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, teacherStudentsSuccessBody{students})
+}
+
+type addTeacherStudentsRequestBody struct {
+	Students []string `json:"students" binding:"required"`
+}
+
+func addTeacherStudents(c *gin.Context) {
+	var requestBody addTeacherStudentsRequestBody
+	if err := c.BindJSON(&requestBody); err != nil {
+		err := fmt.Errorf(customErrors["invalidDataType"].Message, errors.New("invalidDataType"))
+		respondWithError(c, err)
+		return
+	}
+
+	//Parameter validation (normalize, remove duplicates, check for @gmail.com)
+	studentRegistrationData, err := prepareStudentRegistration(models.StudentRegistrationData[string]{Teacher: c.Param("email"), Students: requestBody.Students})
+	setAuditTargets(c, append([]string{studentRegistrationData.Teacher}, studentRegistrationData.Students...)...)
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	if err := authorizeTeacher(c, studentRegistrationData.Teacher, "register students"); err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	err = models.RegisterStudents(c.Request.Context(), studentRegistrationData)
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+type studentSuspensionSuccessBody struct {
+	Suspended bool `json:"suspended"`
+}
+
+func getStudentSuspension(c *gin.Context) {
+	student, ok := emailParam(c, "email")
+	if !ok { return }
+
+	suspended, err := models.GetStudentSuspension(c.Request.Context(), student)
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	c.IndentedJSON(http.StatusOK, studentSuspensionSuccessBody{suspended})
+}
+
+func putStudentSuspension(c *gin.Context) {
+	student, ok := emailParam(c, "email")
+	if !ok { return }
+	setAuditTargets(c, student)
+
+	err := models.SuspendStudent(c.Request.Context(), models.StudentSuspensionData[string]{Student: student})
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func deleteStudentSuspension(c *gin.Context) {
+	student, ok := emailParam(c, "email")
+	if !ok { return }
+	setAuditTargets(c, student)
+
+	err := models.UnsuspendStudent(c.Request.Context(), models.StudentSuspensionData[string]{Student: student})
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func createNotification(c *gin.Context) {
+	var notificationData models.RetrieveForNotificationsData
+	if err := c.BindJSON(&notificationData); err != nil {
+		err := fmt.Errorf(customErrors["invalidDataType"].Message, errors.New("invalidDataType"))
+		respondWithError(c, err)
+		return
+	}
+	if notificationData.DryRun {
+		setAuditAction(c, notifyPreviewAuditAction)
+	}
+
+	//Parameter validation (normalize, remove duplicates, check for @gmail.com)
+	processedData, err := prepareNotification(notificationData)
+	setAuditTargets(c, append([]string{processedData.Teacher}, processedData.Students...)...)
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	if err := authorizeTeacher(c, processedData.Teacher, "send notifications"); err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	// Nothing is created by a dry run, so it answers 200 as over v1
+	if notificationData.DryRun {
+		previewNotification(c, processedData)
+		return
+	}
+
+	if notificationData.SendAt != nil {
+		scheduleNotification(c, processedData, *notificationData.SendAt)
+		return
+	}
+
+	recipients, err := models.RetrieveForNotifications(c.Request.Context(), processedData)
+	if err != nil {
+		respondWithError(c, err)
+		return
+	}
+
+	c.IndentedJSON(http.StatusCreated, retrieveForNotificationsSuccessBody{Recipients: recipients})
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"onecv-go-backend/models"
+	"regexp"
+	"testing"
+
+	"github.com/pashagolub/pgxmock/v3"
+)
+
+func TestV2(t *testing.T) {
+	testCases := []routeTestCase{
+		{
+			"List a teacher's students",
+			"GET", "/api/v2/teachers/Tom@Gmail.com/students", nil,
+			func(mock pgxmock.PgxConnIface) {
+				addCheckTeacherExistsQuery(mock, "tom@gmail.com", true)
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT student FROM teacher_student_relationship WHERE teacher = $1 ORDER BY student")).WithArgs("tom@gmail.com").
+					WillReturnRows(pgxmock.NewRows([]string{"student"}).AddRow("jerry@gmail.com").AddRow("spike@gmail.com"))
+			},
+			200,
+			map[string]any{"students": []any{"jerry@gmail.com", "spike@gmail.com"}},
+		},
+		{
+			"List the students of an invalid email",
+			"GET", "/api/v2/teachers/tomgmail.com/students", nil,
+			nil,
+			customErrors["invalidEmail"].Status,
+			errorResponseBody{Message: fmt.Errorf(customErrors["invalidEmail"].Message, errors.New("invalidEmail"), "'tomgmail.com'").Error()},
+		},
+		{
+			"List the students of a non-existent teacher",
+			"GET", "/api/v2/teachers/tom@gmail.com/students", nil,
+			func(mock pgxmock.PgxConnIface) { addCheckTeacherExistsQuery(mock, "tom@gmail.com", false) },
+			models.CustomErrors["nonExistentTeacher"].Status,
+			errorResponseBody{Message: fmt.Errorf(models.CustomErrors["nonExistentTeacher"].Message, errors.New("nonExistentTeacher"), "tom@gmail.com").Error()},
+		},
+		{
+			"Register students to a teacher",
+			"POST", "/api/v2/teachers/tom@gmail.com/students", addTeacherStudentsRequestBody{Students: []string{"Jerry@gmail.com"}},
+			func(mock pgxmock.PgxConnIface) {
+				addCheckTeacherExistsQuery(mock, "tom@gmail.com", true)
+				addCheckStudentExistsQueries(mock, []string{"jerry@gmail.com"}, []bool{true})
+				addCheckTeacherStudentRelationshipExistsQueries(mock, "tom@gmail.com", []string{"jerry@gmail.com"}, []bool{false})
+				mock.ExpectBegin()
+				mock.ExpectQuery(regexp.QuoteMeta("INSERT INTO teacher_student_relationship(teacher, student) VALUES ($1, $2)")).WithArgs("tom@gmail.com", "jerry@gmail.com").WillReturnRows(pgxmock.NewRows([]string{}))
+				mock.ExpectCommit()
+				addRecordAuditEventQuery(mock, "register", []string{"tom@gmail.com", "jerry@gmail.com"}, 204, nil)
+			},
+			204,
+			map[string]any(nil),
+		},
+		{
+			"Check a student's suspension",
+			"GET", "/api/v2/students/jerry@gmail.com/suspension", nil,
+			func(mock pgxmock.PgxConnIface) {
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT COALESCE(suspended, false) FROM student WHERE email = $1")).WithArgs("jerry@gmail.com").
+					WillReturnRows(pgxmock.NewRows([]string{"suspended"}).AddRow(true))
+			},
+			200,
+			map[string]any{"suspended": true},
+		},
+		{
+			"Check a non-existent student's suspension",
+			"GET", "/api/v2/students/jerry@gmail.com/suspension", nil,
+			func(mock pgxmock.PgxConnIface) {
+				mock.ExpectQuery(regexp.QuoteMeta("SELECT COALESCE(suspended, false) FROM student WHERE email = $1")).WithArgs("jerry@gmail.com").
+					WillReturnRows(pgxmock.NewRows([]string{"suspended"}))
+			},
+			models.CustomErrors["nonExistentStudent"].Status,
+			errorResponseBody{Message: fmt.Errorf(models.CustomErrors["nonExistentStudent"].Message, errors.New("nonExistentStudent"), "jerry@gmail.com").Error()},
+		},
+		{
+			"Suspend a student",
+			"PUT", "/api/v2/students/jerry@gmail.com/suspension", nil,
+			func(mock pgxmock.PgxConnIface) {
+				addCheckStudentExistsQuery(mock, "jerry@gmail.com", true)
+				mock.ExpectQuery(regexp.QuoteMeta("UPDATE student SET suspended = true WHERE email = $1")).WithArgs("jerry@gmail.com").WillReturnRows(pgxmock.NewRows([]string{}))
+				addRecordAuditEventQuery(mock, "suspend", []string{"jerry@gmail.com"}, 204, nil)
+			},
+			204,
+			map[string]any(nil),
+		},
+		{
+			"Lift a student's suspension",
+			"DELETE", "/api/v2/students/jerry@gmail.com/suspension", nil,
+			func(mock pgxmock.PgxConnIface) {
+				addCheckStudentExistsQuery(mock, "jerry@gmail.com", true)
+				mock.ExpectQuery(regexp.QuoteMeta("UPDATE student SET suspended = false WHERE email = $1")).WithArgs("jerry@gmail.com").WillReturnRows(pgxmock.NewRows([]string{}))
+				addRecordAuditEventQuery(mock, "unsuspend", []string{"jerry@gmail.com"}, 204, nil)
+			},
+			204,
+			map[string]any(nil),
+		},
+		{
+			"Send a notification",
+			"POST", "/api/v2/notifications", models.RetrieveForNotificationsData{Teacher: "tom@gmail.com", Notification: "Hello @spike@gmail.com"},
+			func(mock pgxmock.PgxConnIface) {
+				addCheckTeacherExistsQuery(mock, "tom@gmail.com", true)
+				addCheckStudentExistsQueries(mock, []string{"spike@gmail.com"}, []bool{true})
+				mock.ExpectQuery(regexp.QuoteMeta(`
+		SELECT array_agg(DISTINCT student) AS students
+		FROM teacher_student_relationship
+		WHERE teacher = $1
+		GROUP BY teacher
+	`)).WithArgs("tom@gmail.com").WillReturnRows(pgxmock.NewRows([]string{"students"}).AddRow([]string{"jerry@gmail.com"}))
+				addCheckStudentSuspendedQuery(mock, "spike@gmail.com", false)
+				addCheckStudentSuspendedQuery(mock, "jerry@gmail.com", true)
+				addRecordNotificationQuery(mock, "tom@gmail.com", "Hello @spike@gmail.com", []string{"spike@gmail.com"})
+				addRecordAuditEventQuery(mock, "notify", []string{"tom@gmail.com", "spike@gmail.com"}, 201, nil)
+			},
+			201,
+			map[string]any{"recipients": []any{"spike@gmail.com"}},
+		},
+		{
+			"Preview a notification without sending it",
+			"POST", "/api/v2/notifications", models.RetrieveForNotificationsData{Teacher: "tom@gmail.com", Notification: "Hello @spike@gmail.com", DryRun: true},
+			func(mock pgxmock.PgxConnIface) {
+				addCheckTeacherExistsQuery(mock, "tom@gmail.com", true)
+				addCheckStudentExistsQueries(mock, []string{"spike@gmail.com"}, []bool{true})
+				mock.ExpectQuery(regexp.QuoteMeta(`
+		SELECT array_agg(DISTINCT student) AS students
+		FROM teacher_student_relationship
+		WHERE teacher = $1
+		GROUP BY teacher
+	`)).WithArgs("tom@gmail.com").WillReturnRows(pgxmock.NewRows([]string{"students"}).AddRow([]string{"jerry@gmail.com"}))
+				addCheckStudentSuspendedQuery(mock, "spike@gmail.com", false)
+				addCheckStudentSuspendedQuery(mock, "jerry@gmail.com", true)
+				addRecordAuditEventQuery(mock, "notify_preview", []string{"tom@gmail.com", "spike@gmail.com"}, 200, nil)
+			},
+			200,
+			map[string]any{
+				"recipients": []any{"spike@gmail.com"},
+				"students": []any{
+					map[string]any{"email": "jerry@gmail.com", "registered": true, "mentioned": false, "suspended": true},
+					map[string]any{"email": "spike@gmail.com", "registered": false, "mentioned": true, "suspended": false},
+				},
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.testCaseDesc, func(t *testing.T) {
+			runRouteTest(t, testRouter, testCase)
+		})
+	}
+}